@@ -0,0 +1,171 @@
+// mockidp 是一个用于开发和测试的 OpenID Connect 身份提供方，不做任何身份验证，
+// 在授权页面填写任意账号信息即可完成登录。在 OAUTH_PROVIDERS 配置文件中添加：
+//
+//	[{"name": "mock", "display_name": "Mock", "issuer": "http://localhost:9000",
+//	  "client_id": "blog", "client_secret": "secret"}]
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// identity 是授权时填写的账号信息。
+type identity struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// grant 是一次授权产生的授权码。
+type grant struct {
+	identity      identity
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+}
+
+var (
+	addr         = flag.String("addr", ":9000", "监听地址")
+	issuer       = flag.String("issuer", "http://localhost:9000", "issuer 地址，需要与博客服务配置的一致")
+	clientID     = flag.String("client-id", "blog", "应用 ID")
+	clientSecret = flag.String("client-secret", "secret", "应用密钥")
+
+	mu     sync.Mutex
+	codes  = map[string]grant{}
+	tokens = map[string]identity{}
+)
+
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html><body>
+<h3>Mock 登录</h3>
+<form method="post">
+{{range $k, $v := .}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+<p>sub <input name="sub" value="user-1"></p>
+<p>name <input name="name" value="Mock User"></p>
+<p>email <input name="email" value="mock@example.com"></p>
+<p><label><input type="checkbox" name="email_verified" value="true" checked> email verified</label></p>
+<button>登录</button>
+</form>
+</body></html>`))
+
+func main() {
+	flag.Parse()
+	http.HandleFunc("/.well-known/openid-configuration", discovery)
+	http.HandleFunc("/authorize", authorize)
+	http.HandleFunc("/token", token)
+	http.HandleFunc("/userinfo", userinfo)
+	log.Printf("mock identity provider listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// discovery 返回 OpenID Connect Discovery 文档。
+func discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                           *issuer,
+		"authorization_endpoint":           *issuer + "/authorize",
+		"token_endpoint":                   *issuer + "/token",
+		"userinfo_endpoint":                *issuer + "/userinfo",
+		"response_types_supported":         []string{"code"},
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+// authorize 展示填写账号信息的页面，提交后携带授权码跳转回应用。
+// 请求中带有 sub 参数时直接完成授权，便于脚本测试。
+func authorize(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.Form.Get("client_id") != *clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("sub") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		authorizePage.Execute(w, r.URL.Query())
+		return
+	}
+	code := randomToken()
+	mu.Lock()
+	codes[code] = grant{
+		identity: identity{
+			Subject:       r.Form.Get("sub"),
+			Name:          r.Form.Get("name"),
+			Email:         r.Form.Get("email"),
+			EmailVerified: r.Form.Get("email_verified") == "true",
+		},
+		clientID:      r.Form.Get("client_id"),
+		redirectURI:   r.Form.Get("redirect_uri"),
+		codeChallenge: r.Form.Get("code_challenge"),
+	}
+	mu.Unlock()
+	redirect, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token 校验授权码、应用密钥和 PKCE，发放 access token。授权码只能使用一次。
+func token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	mu.Lock()
+	g, ok := codes[r.Form.Get("code")]
+	delete(codes, r.Form.Get("code"))
+	mu.Unlock()
+	if !ok || r.Form.Get("client_id") != g.clientID || r.Form.Get("client_secret") != *clientSecret ||
+		r.Form.Get("redirect_uri") != g.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if g.codeChallenge != "" {
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	}
+	accessToken := randomToken()
+	mu.Lock()
+	tokens[accessToken] = g.identity
+	mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": accessToken, "token_type": "Bearer", "expires_in": 3600})
+}
+
+// userinfo 返回 access token 对应的账号信息。
+func userinfo(w http.ResponseWriter, r *http.Request) {
+	mu.Lock()
+	id, ok := tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, id)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
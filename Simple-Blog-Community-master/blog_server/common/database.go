@@ -34,6 +34,10 @@ func InitDB() *gorm.DB {
 	}
 	// 迁移数据表
 	db.AutoMigrate(&model.User{})
+	// 邮箱只在验证后唯一，未验证的邮箱不能阻止真正的所有者绑定，移除旧版本建立的唯一索引
+	if db.Dialect().HasIndex("users", "uix_users_email") {
+		db.Model(&model.User{}).RemoveIndex("uix_users_email")
+	}
 	//自动建表
 	DB = db
 	return db
@@ -2,6 +2,8 @@ package common
 
 import (
 	"blog_server/model"
+	"crypto/hmac"
+	"crypto/sha256"
 	"github.com/dgrijalva/jwt-go"
 	"time"
 )
@@ -9,19 +11,25 @@ import (
 // jwt加密密钥
 var jwtKey = []byte("a_secret_key")
 
+// TokenTTL 是 token 的有效期
+const TokenTTL = 7 * 24 * time.Hour
+
 type Claims struct {
-	UserId uint
+	UserId       uint
+	TokenVersion int // 发放时用户的 token 版本，与用户当前版本不一致时 token 失效
 	jwt.StandardClaims
 }
 
-func ReleaseToken(user model.User) (string, error) {
-	// token的有效期
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
+// ReleaseToken 为用户发放 token，sessionId 是对应会话的 ID，保存在标准字段 jti 中
+func ReleaseToken(user model.User, sessionId string, expirationTime time.Time) (string, error) {
 	claims := &Claims{
 		// 自定义字段
-		UserId: user.ID,
+		UserId:       user.ID,
+		TokenVersion: user.TokenVersion,
 		// 标准字段
 		StandardClaims: jwt.StandardClaims{
+			// 会话ID
+			Id: sessionId,
 			// 过期时间
 			ExpiresAt: expirationTime.Unix(),
 			// 发放时间
@@ -45,3 +53,10 @@ func ParseToken(tokenString string) (*jwt.Token, *Claims, error) {
 	})
 	return token, claims, err
 }
+
+// Sign 使用 jwt 密钥计算数据的 HMAC-SHA256 签名，用于邮件链接等不需要完整 jwt 的场景。
+func Sign(data string) []byte {
+	mac := hmac.New(sha256.New, jwtKey)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"time"
+)
+
+const (
+	exportCooldown = 24 * time.Hour   // 两次申请导出个人数据之间的最短间隔
+	reauthWindow   = 10 * time.Minute // 不提供密码申请注销时，当前会话需要在该时间内登录
+)
+
+// AccountController 结构体用于处理注销账号和导出个人数据相关的请求。
+type AccountController struct {
+	DB *gorm.DB
+}
+
+// IAccountController 接口定义了账号控制器需要实现的一系列方法。
+type IAccountController interface {
+	Deletion(c *gin.Context)        // 查询注销申请
+	RequestDeletion(c *gin.Context) // 申请注销账号
+	CancelDeletion(c *gin.Context)  // 撤销注销申请
+	Exports(c *gin.Context)         // 查询数据导出
+	CreateExport(c *gin.Context)    // 申请导出个人数据
+	Download(c *gin.Context)        // 下载导出的数据
+}
+
+// Deletion 查询当前用户的注销申请，scheduled 为 false 表示未申请注销。
+func (a AccountController) Deletion(c *gin.Context) {
+	user, _ := c.Get("user")
+	response.Success(c, deletionStatus(user.(model.User)), "查询成功")
+}
+
+// RequestDeletion 申请注销账号，需要提供密码，开启两步验证时还需要提供验证码。
+// 冷静期内可以正常登录并撤销申请，冷静期结束后账号被注销且无法恢复。
+func (a AccountController) RequestDeletion(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.RequestDeletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = model.DeletionAnonymize
+	}
+	if !contains(model.DeletionModes, request.Mode) {
+		response.Fail(c, nil, "文章处理方式错误")
+		return
+	}
+	if user.(model.User).DeletionScheduledAt != nil {
+		response.Fail(c, nil, "已申请注销")
+		return
+	}
+	// 需要重新验证身份：提供当前密码，或者提供两步验证的动态验证码，或者在最近一段时间内重新登录过，
+	// 后两种方式适用于没有设置密码的外部登录账号
+	if request.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.(model.User).Password), []byte(request.Password)); err != nil {
+			response.Fail(c, nil, "密码错误")
+			return
+		}
+	} else if !(user.(model.User).TOTPEnabled && request.Code != "") && !recentlyAuthenticated(c) {
+		response.Fail(c, nil, "请输入密码，或重新登录后再申请注销")
+		return
+	}
+	if user.(model.User).TOTPEnabled {
+		if _, ok := verifySecondFactor(a.DB, user.(model.User), request.Code); !ok {
+			response.Fail(c, nil, "验证码错误")
+			return
+		}
+	}
+	at, err := service.ScheduleDeletion(a.DB, user.(model.User), request.Mode)
+	if err != nil {
+		response.Fail(c, nil, "申请失败")
+		return
+	}
+	service.Audit(a.DB, c, user.(model.User).ID, model.AuditDeletionRequest, "user", strconv.Itoa(int(user.(model.User).ID)),
+		nil, gin.H{"mode": request.Mode, "scheduled_at": model.Time(at)})
+	service.Notify(a.DB, user.(model.User).ID, 0, model.NotifySystem, "",
+		"你已申请注销账号，账号将于 "+model.Time(at).String()+" 注销，在此之前可以随时撤销申请")
+	updated := user.(model.User)
+	scheduled := model.Time(at)
+	updated.DeletionScheduledAt = &scheduled
+	updated.DeletionMode = request.Mode
+	response.Success(c, deletionStatus(updated), "已申请注销")
+}
+
+// CancelDeletion 在冷静期内撤销注销申请。
+func (a AccountController) CancelDeletion(c *gin.Context) {
+	user, _ := c.Get("user")
+	if user.(model.User).DeletionScheduledAt == nil {
+		response.Fail(c, nil, "未申请注销")
+		return
+	}
+	if err := service.CancelDeletion(a.DB, user.(model.User)); err != nil {
+		response.Fail(c, nil, "撤销失败")
+		return
+	}
+	service.Audit(a.DB, c, user.(model.User).ID, model.AuditDeletionCancel, "user", strconv.Itoa(int(user.(model.User).ID)), nil, nil)
+	response.Success(c, nil, "已撤销注销申请")
+}
+
+// Exports 查询当前用户最近的数据导出记录。
+func (a AccountController) Exports(c *gin.Context) {
+	user, _ := c.Get("user")
+	var exports []model.DataExport
+	a.DB.Where("user_id = ?", user.(model.User).ID).Order("id desc").Limit(10).Find(&exports)
+	response.Success(c, gin.H{"exports": exports}, "查找成功")
+}
+
+// CreateExport 申请导出个人数据，导出文件在后台生成，完成后通过站内通知告知用户。
+func (a AccountController) CreateExport(c *gin.Context) {
+	user, _ := c.Get("user")
+	var last model.DataExport
+	if !a.DB.Where("user_id = ?", user.(model.User).ID).Order("id desc").First(&last).RecordNotFound() {
+		if last.Status == model.ExportPending || last.Status == model.ExportRunning {
+			response.Fail(c, nil, "导出正在生成中")
+			return
+		}
+		if time.Since(time.Time(last.CreatedAt)) < exportCooldown && last.Status != model.ExportFailed {
+			response.Fail(c, nil, "每天只能申请一次导出")
+			return
+		}
+	}
+	export := model.DataExport{UserId: user.(model.User).ID, Status: model.ExportPending}
+	if err := a.DB.Create(&export).Error; err != nil {
+		response.Fail(c, nil, "申请失败")
+		return
+	}
+	service.QueueExport()
+	service.Audit(a.DB, c, user.(model.User).ID, model.AuditDataExport, "export", strconv.Itoa(int(export.ID)), nil, nil)
+	response.Success(c, gin.H{"export": export}, "已开始生成，完成后会通知你")
+}
+
+// Download 下载已生成的导出文件。
+func (a AccountController) Download(c *gin.Context) {
+	user, _ := c.Get("user")
+	var export model.DataExport
+	if a.DB.Where("id = ? AND user_id = ?", c.Params.ByName("id"), user.(model.User).ID).First(&export).RecordNotFound() {
+		response.Fail(c, nil, "导出不存在")
+		return
+	}
+	if export.Status != model.ExportDone || export.ExpiresAt == nil || time.Time(*export.ExpiresAt).Before(time.Now()) {
+		response.Fail(c, nil, "导出文件尚未生成或已过期")
+		return
+	}
+	c.FileAttachment(export.File, "blog-export-"+time.Time(export.CreatedAt).Format("20060102")+".zip")
+}
+
+// recentlyAuthenticated 判断当前会话是否在 reauthWindow 内登录，包括通过外部登录服务登录。
+func recentlyAuthenticated(c *gin.Context) bool {
+	session, ok := c.Get("session")
+	return ok && time.Since(time.Time(session.(model.Session).CreatedAt)) < reauthWindow
+}
+
+// deletionStatus 返回用户的注销申请状态。
+func deletionStatus(user model.User) gin.H {
+	if user.DeletionScheduledAt == nil {
+		return gin.H{"scheduled": false}
+	}
+	return gin.H{
+		"scheduled":    true,
+		"mode":         user.DeletionMode,
+		"scheduled_at": *user.DeletionScheduledAt,
+	}
+}
+
+// NewAccountController 函数用于创建并初始化 AccountController 实例。
+func NewAccountController() IAccountController {
+	db := common.GetDB()
+	db.AutoMigrate(model.DataExport{})
+	return &AccountController{DB: db}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"time"
+)
+
+// AdminUserController 结构体用于管理员封禁和解封用户。
+type AdminUserController struct {
+	DB *gorm.DB
+}
+
+// IAdminUserController 接口定义了用户管理控制器需要实现的一系列方法。
+type IAdminUserController interface {
+	Suspended(c *gin.Context) // 查询被封禁的用户
+	Suspend(c *gin.Context)   // 封禁用户
+	Reinstate(c *gin.Context) // 解除封禁
+}
+
+// Suspended 分页查询当前处于封禁状态的用户。
+func (a AdminUserController) Suspended(c *gin.Context) {
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	query := a.DB.Model(model.User{}).Where("suspended_until > ?", time.Now())
+	var users []model.User
+	var count int
+	query.Order("suspended_until desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&users)
+	query.Count(&count)
+	list := make([]gin.H, 0, len(users))
+	for _, user := range users {
+		list = append(list, gin.H{
+			"id":              user.ID,
+			"name":            user.UserName,
+			"avatar":          user.Avatar,
+			"suspended_until": *user.SuspendedUntil,
+			"permanent":       user.IsPermanentlySuspended(),
+			"reason":          user.SuspendReason,
+			"hide_content":    user.ContentHidden,
+		})
+	}
+	response.Success(c, gin.H{"users": list, "count": count}, "查找成功")
+}
+
+// Suspend 封禁用户一段时间或永久封禁，可选择在封禁期间隐藏其文章。
+func (a AdminUserController) Suspend(c *gin.Context) {
+	var request vo.SuspendUserRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	if !request.Permanent && request.Days <= 0 {
+		response.Fail(c, nil, "封禁天数错误")
+		return
+	}
+	var target model.User
+	if a.DB.Where("id = ?", c.Params.ByName("id")).First(&target).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	if target.Role == model.RoleAdmin {
+		response.Fail(c, nil, "不能封禁管理员")
+		return
+	}
+	until := model.PermanentSuspension
+	if !request.Permanent {
+		until = time.Now().AddDate(0, 0, request.Days)
+	}
+	if err := service.SuspendUser(a.DB, target, until, request.Reason, request.HideContent); err != nil {
+		response.Fail(c, nil, "封禁失败")
+		return
+	}
+	a.audit(c, model.AuditUserSuspend, target)
+	response.Success(c, gin.H{"suspended_until": model.Time(until)}, "封禁成功")
+}
+
+// Reinstate 提前解除用户的封禁。
+func (a AdminUserController) Reinstate(c *gin.Context) {
+	var target model.User
+	if a.DB.Where("id = ?", c.Params.ByName("id")).First(&target).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	if err := service.ReinstateUser(a.DB, target); err != nil {
+		response.Fail(c, nil, "解封失败")
+		return
+	}
+	a.audit(c, model.AuditUserReinstate, target)
+	user, _ := c.Get("user")
+	service.Notify(a.DB, target.ID, user.(model.User).ID, model.NotifySystem, "", "你的账号已被管理员解除封禁")
+	response.Success(c, nil, "解封成功")
+}
+
+// audit 记录封禁或解封操作的审计日志，before 为操作前的用户。
+func (a AdminUserController) audit(c *gin.Context, action string, before model.User) {
+	user, _ := c.Get("user")
+	var after model.User
+	a.DB.Where("id = ?", before.ID).First(&after)
+	service.Audit(a.DB, c, user.(model.User).ID, action, "user", strconv.Itoa(int(before.ID)),
+		service.UserSnapshot(before), service.UserSnapshot(after))
+}
+
+// NewAdminUserController 函数用于创建并初始化 AdminUserController 实例。
+func NewAdminUserController() IAdminUserController {
+	return &AdminUserController{DB: common.GetDB()}
+}
@@ -4,6 +4,7 @@ import (
 	"blog_server/common"
 	"blog_server/model"
 	"blog_server/response"
+	"blog_server/service"
 	"blog_server/vo"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -20,11 +21,13 @@ type ArticleController struct {
 
 // IArticleController 接口定义了文章控制器需要实现的一系列方法。
 type IArticleController interface {
-	Create(c *gin.Context) // 创建文章的方法
-	Update(c *gin.Context) // 更新文章的方法
-	Delete(c *gin.Context) // 删除文章的方法
-	Show(c *gin.Context)   // 显示文章详情的方法
-	List(c *gin.Context)   // 列出所有文章的方法
+	Create(c *gin.Context)  // 创建文章的方法
+	Update(c *gin.Context)  // 更新文章的方法
+	Delete(c *gin.Context)  // 删除文章的方法
+	Show(c *gin.Context)    // 显示文章详情的方法
+	List(c *gin.Context)    // 列出所有文章的方法
+	Rank(c *gin.Context)    // 文章排行榜的方法
+	Related(c *gin.Context) // 相关文章推荐的方法
 
 }
 
@@ -41,6 +44,12 @@ func (a ArticleController) Create(c *gin.Context) {
 		return
 	}
 
+	// 检查标题和正文中的敏感词。
+	action, words, ok := filterArticle(c, &articleRequest)
+	if !ok {
+		return
+	}
+
 	// 从 Gin 上下文中获取当前登录的用户信息。
 	// 这里假设 Gin 上下文中存储的用户信息的键是 "user"。
 	user, _ := c.Get("user")
@@ -62,6 +71,19 @@ func (a ArticleController) Create(c *gin.Context) {
 		return
 	}
 
+	// 命中需要审核的敏感词时提交给版主审核。
+	if action == model.WordReview {
+		service.FlagForReview(a.DB, model.ReportArticle, article.ID.String(), article.UserId, words)
+	}
+
+	// 向关注了作者的用户实时推送新文章。
+	service.PublishToFollowers(a.DB, article.UserId, "article", gin.H{
+		"id":        article.ID,
+		"title":     article.Title,
+		"author_id": article.UserId,
+		"author":    user.(model.User).UserName,
+	})
+
 	// 如果创建成功，返回成功响应和新创建的文章 ID。
 	response.Success(c, gin.H{"id": article.ID}, "发布成功")
 }
@@ -86,10 +108,17 @@ func (a ArticleController) Update(c *gin.Context) {
 		response.Fail(c, nil, "登录用户不正确")
 		return
 	}
+	action, words, ok := filterArticle(c, &articleRequest)
+	if !ok {
+		return
+	}
 	if err := a.DB.Model(&article).Updates(articleRequest).Error; err != nil {
 		response.Fail(c, nil, "修改失败")
 		return
 	}
+	if action == model.WordReview {
+		service.FlagForReview(a.DB, model.ReportArticle, articleId, article.UserId, words)
+	}
 	response.Success(c, nil, "修改成功")
 }
 
@@ -108,13 +137,42 @@ func (a ArticleController) Delete(c *gin.Context) {
 		response.Fail(c, nil, "登录用户不正确")
 		return
 	}
-	if err := a.DB.Delete(&article).Error; err != nil {
+	if err := deleteArticle(a.DB, article); err != nil {
 		response.Fail(c, nil, "删除失败")
 		return
 	}
+	service.Audit(a.DB, c, userId, model.AuditArticleDelete, model.ReportArticle, articleId, article, nil)
 	response.Success(c, nil, "删除成功")
 }
 
+// filterArticle 检查文章标题和正文中的敏感词，并将需要屏蔽的敏感词替换为星号。
+// 命中需要拒绝的敏感词时返回错误响应，ok 为 false。
+func filterArticle(c *gin.Context, request *vo.CreateArticleRequest) (action string, words []string, ok bool) {
+	filter := service.GetFilter()
+	title := filter.Check(request.Title)
+	content := filter.Check(request.Content)
+	if title.Action == model.WordReject || content.Action == model.WordReject {
+		response.Fail(c, gin.H{"words": append(title.Words, content.Words...)}, "内容包含敏感词")
+		return "", nil, false
+	}
+	request.Title = title.Masked
+	request.Content = content.Masked
+	if title.Action == model.WordReview || content.Action == model.WordReview {
+		action = model.WordReview
+	}
+	return action, append(title.Words, content.Words...), true
+}
+
+// deleteArticle 删除文章，同时删除文章的点赞记录与相关文章推荐。
+func deleteArticle(db *gorm.DB, article model.Article) error {
+	if err := db.Delete(&article).Error; err != nil {
+		return err
+	}
+	db.Where("article_id = ?", article.ID).Delete(model.Like{})
+	db.Where("article_id = ? OR related_id = ?", article.ID, article.ID).Delete(model.RelatedArticle{})
+	return nil
+}
+
 // Show 方法实现 IArticleController 接口的显示文章详情功能。
 // 它根据文章 ID 查找并显示文章的详细信息。
 func (a ArticleController) Show(c *gin.Context) {
@@ -124,7 +182,23 @@ func (a ArticleController) Show(c *gin.Context) {
 		response.Fail(c, nil, "文章不存在")
 		return
 	}
-	response.Success(c, gin.H{"article": article}, "查找成功")
+	user, ok := c.Get("user")
+	if !canViewArticle(a.DB, article, user) {
+		response.Fail(c, nil, "文章不存在")
+		return
+	}
+	// 已登录时返回当前用户是否点赞，并以用户 ID 作为浏览去重的访客标识
+	var liked int
+	visitor := "ip:" + c.ClientIP() + "|" + c.Request.UserAgent()
+	if ok {
+		a.DB.Model(model.Like{}).Where("user_id = ? AND article_id = ?", user.(model.User).ID, articleId).Count(&liked)
+		visitor = "user:" + strconv.Itoa(int(user.(model.User).ID))
+	}
+	// 记录浏览，返回的浏览数包含尚未写入数据库的部分
+	views := service.GetViewCounter()
+	views.Record(articleId, visitor, c.Request.UserAgent())
+	article.ViewCount += views.Pending(articleId)
+	response.Success(c, gin.H{"article": article, "liked": liked > 0}, "查找成功")
 }
 
 // List 方法实现 IArticleController 接口的列出所有文章功能。
@@ -134,7 +208,8 @@ func (a ArticleController) List(c *gin.Context) {
 	categoryId := c.DefaultQuery("categoryId", "0")
 	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "5"))
-	var query []string
+	// 不列出被版主隐藏或作者被封禁并隐藏内容的文章
+	query := []string{visibleArticles("")}
 	var args []interface{}
 
 	if keyword != "" {
@@ -146,34 +221,144 @@ func (a ArticleController) List(c *gin.Context) {
 		args = append(args, categoryId)
 	}
 
+	// 登录用户屏蔽或静音的作者的文章不出现在列表中
+	user, _ := c.Get("user")
+	if user != nil {
+		if hidden := service.HiddenAuthorIds(a.DB, user.(model.User).ID); len(hidden) > 0 {
+			query = append(query, "user_id NOT IN (?)")
+			args = append(args, hidden)
+		}
+	}
+
 	var article []model.ArticleInfo
 	var count int
-	var querystr string
-	if len(query) > 0 {
-		querystr = strings.Join(query, " AND ")
-	}
-
-	switch len(args) {
-	case 0:
-		a.DB.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-			Order("created_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&article)
-		a.DB.Model(model.Article{}).Count(&count)
-	case 1:
-		a.DB.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-			Where(querystr, args[0]).Order("created_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&article)
-		a.DB.Model(model.Article{}).Where(querystr, args[0]).Count(&count)
-	case 2:
-		a.DB.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-			Where(querystr, args[0], args[1]).Order("created_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&article)
-		a.DB.Model(model.Article{}).Where(querystr, args[0], args[1]).Count(&count)
-	case 3:
-		a.DB.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-			Where(querystr, args[0], args[1], args[2]).Order("created_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&article)
-		a.DB.Model(model.Article{}).Where(querystr, args[0], args[1], args[2]).Count(&count)
-	}
+	db := a.DB.Table("articles").Where(strings.Join(query, " AND "), args...)
+	db.Select(articleInfoFields("")).Order("created_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&article)
+	db.Count(&count)
 
+	markLiked(a.DB, user, article)
 	response.Success(c, gin.H{"article": article, "count": count}, "查找成功")
 }
+
+// Rank 方法实现 IArticleController 接口的文章排行榜功能。
+// 排行榜由后台定期计算并缓存，可以通过分类 ID 筛选。
+func (a ArticleController) Rank(c *gin.Context) {
+	kind := c.Params.ByName("kind")
+	if !service.IsRankKind(kind) {
+		response.Fail(c, nil, "排行榜类型错误")
+		return
+	}
+	categoryId, _ := strconv.Atoi(c.DefaultQuery("categoryId", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	ids, updatedAt := service.GetRanker().Top(kind, uint(categoryId), 0)
+	user, _ := c.Get("user")
+	articles := hideArticles(a.DB, user, findArticleInfos(a.DB, ids), limit)
+	markLiked(a.DB, user, articles)
+	response.Success(c, gin.H{"article": articles, "updated_at": model.Time(updatedAt)}, "查找成功")
+}
+
+// Related 方法实现 IArticleController 接口的相关文章推荐功能。
+// 推荐结果由后台定期计算，尚未计算过的文章返回同分类下的最新文章。
+func (a ArticleController) Related(c *gin.Context) {
+	articleId := c.Params.ByName("id")
+	var article model.Article
+	if a.DB.Where("id = ?", articleId).First(&article).RecordNotFound() {
+		response.Fail(c, nil, "文章不存在")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit <= 0 || limit > 10 {
+		limit = 5
+	}
+	var ids []string
+	a.DB.Model(model.RelatedArticle{}).Where("article_id = ?", articleId).
+		Order("position").Pluck("related_id", &ids)
+	if len(ids) == 0 {
+		a.DB.Model(model.Article{}).Where("category_id = ? AND id <> ?", article.CategoryId, articleId).
+			Order("created_at desc").Limit(10).Pluck("id", &ids)
+	}
+	user, _ := c.Get("user")
+	articles := hideArticles(a.DB, user, findArticleInfos(a.DB, ids), limit)
+	markLiked(a.DB, user, articles)
+	response.Success(c, gin.H{"article": articles}, "查找成功")
+}
+
+// findArticleInfos 根据文章 ID 查询文章列表信息，并保持传入 ID 的顺序，已删除或不可见的文章会被忽略。
+func findArticleInfos(db *gorm.DB, ids []string) []model.ArticleInfo {
+	articles := []model.ArticleInfo{}
+	if len(ids) == 0 {
+		return articles
+	}
+	var found []model.ArticleInfo
+	db.Table("articles").Select(articleInfoFields("")).Where("id IN (?)", ids).Where(visibleArticles("")).Find(&found)
+	byId := make(map[string]model.ArticleInfo, len(found))
+	for _, article := range found {
+		byId[article.ID] = article
+	}
+	for _, id := range ids {
+		if article, ok := byId[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	return articles
+}
+
+// hideArticles 去除登录用户屏蔽或静音的作者的文章，并最多保留 limit 篇。
+func hideArticles(db *gorm.DB, user interface{}, articles []model.ArticleInfo, limit int) []model.ArticleInfo {
+	hidden := map[uint]bool{}
+	if user != nil {
+		for _, id := range service.HiddenAuthorIds(db, user.(model.User).ID) {
+			hidden[id] = true
+		}
+	}
+	visible := articles[:0]
+	for _, article := range articles {
+		if len(visible) == limit {
+			break
+		}
+		if !hidden[article.UserId] {
+			visible = append(visible, article)
+		}
+	}
+	return visible
+}
+
+// visibleArticles 返回筛选可公开展示的文章的条件：未被版主隐藏，且作者未被封禁并隐藏内容。
+// table 不为空时为字段加上表名前缀。
+func visibleArticles(table string) string {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	return prefix + "hidden = 0 AND " + prefix + "user_id NOT IN (SELECT id FROM users WHERE content_hidden = 1)"
+}
+
+// canViewArticle 判断用户能否查看文章，user 为 nil 表示未登录。
+// 被隐藏的文章以及被封禁并隐藏内容的作者的文章，只有作者和版主可以查看。
+func canViewArticle(db *gorm.DB, article model.Article, user interface{}) bool {
+	if user != nil && (user.(model.User).ID == article.UserId || canModerate(user.(model.User))) {
+		return true
+	}
+	if article.Hidden {
+		return false
+	}
+	var author model.User
+	db.Select("id, content_hidden").Where("id = ?", article.UserId).First(&author)
+	return !author.ContentHidden
+}
+
+// articleInfoFields 返回查询文章列表信息时需要选取的字段，table 不为空时为字段加上表名前缀。
+func articleInfoFields(table string) string {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	return prefix + "id, " + prefix + "user_id, " + prefix + "category_id, " + prefix + "title, LEFT(" + prefix + "content,80) AS content, " +
+		prefix + "head_image, " + prefix + "like_count, " + prefix + "view_count, " + prefix + "created_at"
+}
 func (ac *ArticleController) ShowWithComments(c *gin.Context) {
 	articleID, err := uuid.FromString(c.Param("id"))
 	if err != nil {
@@ -193,7 +378,7 @@ func (ac *ArticleController) ShowWithComments(c *gin.Context) {
 // NewArticleController 函数用于创建并初始化 ArticleController 实例。
 // 它获取数据库连接，执行自动迁移，并返回一个实现了 IArticleController 接口的控制器实例。
 func NewArticleController() IArticleController {
-	db := common.GetDB()              // 从 common 包中获取数据库连接
-	db.AutoMigrate(model.Article{})   // 使用 GORM 自动迁移 Article 模型
-	return &ArticleController{DB: db} // 返回初始化好的 ArticleController 实例
+	db := common.GetDB()                                    // 从 common 包中获取数据库连接
+	db.AutoMigrate(model.Article{}, model.RelatedArticle{}) // 使用 GORM 自动迁移 Article 及相关文章模型
+	return &ArticleController{DB: db}                       // 返回初始化好的 ArticleController 实例
 }
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditController 结构体用于管理员查询和导出审计日志。
+type AuditController struct {
+	DB *gorm.DB
+}
+
+// IAuditController 接口定义了审计日志控制器需要实现的一系列方法。
+type IAuditController interface {
+	List(c *gin.Context)   // 查询审计日志
+	Export(c *gin.Context) // 导出审计日志
+}
+
+// List 分页查询审计日志，按时间倒序排列。
+func (a AuditController) List(c *gin.Context) {
+	query, ok := a.filter(c)
+	if !ok {
+		return
+	}
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	var logs []model.AuditLog
+	var count int
+	query.Order("id desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&logs)
+	query.Count(&count)
+	response.Success(c, gin.H{"logs": logs, "count": count}, "查找成功")
+}
+
+// Export 以 JSON Lines 格式导出符合条件的全部审计日志，每行一条，按时间正序排列。
+func (a AuditController) Export(c *gin.Context) {
+	query, ok := a.filter(c)
+	if !ok {
+		return
+	}
+	rows, err := query.Order("id asc").Rows()
+	if err != nil {
+		response.Fail(c, nil, "导出失败")
+		return
+	}
+	defer rows.Close()
+	filename := "audit_" + time.Now().Format("20060102150405") + ".jsonl"
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	encoder := json.NewEncoder(c.Writer)
+	for rows.Next() {
+		var entry model.AuditLog
+		if err := a.DB.ScanRows(rows, &entry); err != nil {
+			return
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return
+		}
+	}
+}
+
+// filter 根据查询参数构建审计日志的筛选条件，支持操作者、操作类型、对象和时间范围，
+// 时间格式为 2006-01-02 或 2006-01-02 15:04:05。参数错误时返回错误响应，ok 为 false。
+func (a AuditController) filter(c *gin.Context) (query *gorm.DB, ok bool) {
+	query = a.DB.Model(model.AuditLog{})
+	if actorId := c.Query("actorId"); actorId != "" {
+		query = query.Where("actor_id = ?", actorId)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetType := c.Query("targetType"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetId := c.Query("targetId"); targetId != "" {
+		query = query.Where("target_id = ?", targetId)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	for param, op := range map[string]string{"from": ">=", "to": "<="} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value, param == "to")
+		if err != nil {
+			response.Fail(c, nil, "时间格式错误")
+			return nil, false
+		}
+		query = query.Where("created_at "+op+" ?", t)
+	}
+	return query, true
+}
+
+// parseTime 解析查询参数中的时间，只有日期且 endOfDay 为 true 时取当天的最后一刻。
+func parseTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err == nil && endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, err
+}
+
+// NewAuditController 函数用于创建并初始化 AuditController 实例。
+func NewAuditController() IAuditController {
+	db := common.GetDB()
+	db.AutoMigrate(model.AuditLog{})
+	protectAuditLogs(db)
+	return &AuditController{DB: db}
+}
+
+// protectAuditLogs 在数据库中创建拒绝修改和删除审计日志的触发器，使原生 SQL 同样无法修改审计日志。
+// 数据库账号没有创建触发器的权限时只输出日志，此时只靠模型上的钩子保证审计日志只能追加。
+func protectAuditLogs(db *gorm.DB) {
+	for _, event := range []string{"UPDATE", "DELETE"} {
+		name := "audit_logs_no_" + strings.ToLower(event)
+		var count int
+		if err := db.Table("information_schema.triggers").
+			Where("trigger_schema = DATABASE() AND trigger_name = ?", name).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		if err := db.Exec("CREATE TRIGGER " + name + " BEFORE " + event + " ON audit_logs FOR EACH ROW " +
+			"SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit logs are append-only'").Error; err != nil {
+			log.Println("create audit log trigger failed, append-only is only enforced by the model hooks:", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+)
+
+// BlockController 结构体用于处理屏蔽用户相关的请求。
+// 屏蔽用户后，对方无法关注你、给你发私信，其文章也不再出现在你的文章列表中。
+type BlockController struct {
+	DB *gorm.DB
+}
+
+// IBlockController 接口定义了屏蔽控制器需要实现的一系列方法。
+type IBlockController interface {
+	List(c *gin.Context)    // 查询屏蔽列表
+	Block(c *gin.Context)   // 屏蔽用户
+	UnBlock(c *gin.Context) // 取消屏蔽
+}
+
+// List 查询当前登录用户屏蔽的用户列表。
+func (b BlockController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	var users []model.UserInfo
+	b.DB.Table("users").Select("users.id, users.avatar, users.user_name").
+		Joins("JOIN blocks ON blocks.blocked_id = users.id").
+		Where("blocks.user_id = ?", user.(model.User).ID).Order("blocks.created_at desc").Scan(&users)
+	response.Success(c, gin.H{"users": users}, "查找成功")
+}
+
+// Block 屏蔽指定用户。
+func (b BlockController) Block(c *gin.Context) {
+	user, _ := c.Get("user")
+	blockedId, _ := strconv.Atoi(c.Params.ByName("id"))
+	if uint(blockedId) == user.(model.User).ID {
+		response.Fail(c, nil, "不能屏蔽自己")
+		return
+	}
+	var blocked model.User
+	if b.DB.Where("id = ?", blockedId).First(&blocked).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	block := model.Block{UserId: user.(model.User).ID, BlockedId: blocked.ID}
+	if !b.DB.Where(&block).First(&model.Block{}).RecordNotFound() {
+		response.Fail(c, nil, "已屏蔽")
+		return
+	}
+	if err := b.DB.Create(&block).Error; err != nil {
+		response.Fail(c, nil, "屏蔽失败")
+		return
+	}
+	// 被屏蔽的用户不能再关注屏蔽者，移除已有的关注并更新粉丝数
+	blockerId := strconv.Itoa(int(block.UserId))
+	var following model.Array
+	for _, id := range blocked.Following {
+		if id != blockerId {
+			following = append(following, id)
+		}
+	}
+	if len(following) < len(blocked.Following) {
+		b.DB.Model(&blocked).Update("following", following)
+		b.DB.Model(model.User{}).Where("id = ? AND fans > 0", block.UserId).
+			UpdateColumn("fans", gorm.Expr("fans - ?", len(blocked.Following)-len(following)))
+	}
+	response.Success(c, nil, "屏蔽成功")
+}
+
+// UnBlock 取消屏蔽指定用户。
+func (b BlockController) UnBlock(c *gin.Context) {
+	user, _ := c.Get("user")
+	if err := b.DB.Where("user_id = ? AND blocked_id = ?", user.(model.User).ID, c.Params.ByName("id")).
+		Delete(model.Block{}).Error; err != nil {
+		response.Fail(c, nil, "取消失败")
+		return
+	}
+	response.Success(c, nil, "取消成功")
+}
+
+// NewBlockController 函数用于创建并初始化 BlockController 实例。
+func NewBlockController() IBlockController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Block{})
+	return &BlockController{DB: db}
+}
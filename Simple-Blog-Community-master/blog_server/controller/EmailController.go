@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	emailVerifyTTL = 24 * time.Hour   // 邮箱验证链接的有效期
+	emailResetTTL  = 30 * time.Minute // 重置密码链接的有效期
+)
+
+// emailPattern 是邮箱的格式，不允许包含空白和签名令牌使用的分隔符。
+var emailPattern = regexp.MustCompile(`^[^@\s|]+@[^@\s|]+\.[^@\s|]+$`)
+
+// EmailController 结构体用于处理邮箱绑定、验证以及通过邮件重置密码的请求。
+type EmailController struct {
+	DB      *gorm.DB
+	BaseURL string // 服务端地址，用于生成邮箱验证链接
+	WebURL  string // 前端地址，用于生成重置密码链接
+}
+
+// IEmailController 接口定义了邮箱控制器需要实现的一系列方法。
+type IEmailController interface {
+	Update(c *gin.Context)         // 绑定或修改邮箱
+	Resend(c *gin.Context)         // 重新发送验证邮件
+	Verify(c *gin.Context)         // 验证邮箱
+	ForgotPassword(c *gin.Context) // 发送重置密码邮件
+	ResetPassword(c *gin.Context)  // 通过邮件中的令牌重置密码
+}
+
+// Update 绑定或修改当前用户的邮箱，修改后需要重新验证。
+func (e EmailController) Update(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.UpdateEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if len(email) > 100 || !emailPattern.MatchString(email) {
+		response.Fail(c, nil, "邮箱格式错误")
+		return
+	}
+	if current := user.(model.User).Email; current != nil && *current == email && user.(model.User).EmailVerified {
+		response.Fail(c, nil, "邮箱未改变")
+		return
+	}
+	// 其他用户绑定但未验证的邮箱仍然可以绑定，先完成验证的一方保留该邮箱
+	if service.EmailTaken(e.DB, email, user.(model.User).ID) {
+		response.Fail(c, nil, service.ErrEmailTaken.Error())
+		return
+	}
+	if err := e.DB.Model(&model.User{}).Where("id = ?", user.(model.User).ID).
+		Updates(map[string]interface{}{"email": email, "email_verified": false}).Error; err != nil {
+		response.Fail(c, nil, "修改失败")
+		return
+	}
+	before := gin.H{}
+	if user.(model.User).Email != nil {
+		before["email"] = *user.(model.User).Email
+	}
+	service.Audit(e.DB, c, user.(model.User).ID, model.AuditEmailChange, "user", strconv.Itoa(int(user.(model.User).ID)), before, gin.H{"email": email})
+	if err := e.sendVerification(user.(model.User).ID, email); err != nil {
+		response.Fail(c, nil, "邮箱已保存，但验证邮件发送失败，请稍后重新发送")
+		return
+	}
+	response.Success(c, gin.H{"email": email}, "验证邮件已发送")
+}
+
+// Resend 重新发送验证邮件。
+func (e EmailController) Resend(c *gin.Context) {
+	user, _ := c.Get("user")
+	if user.(model.User).Email == nil {
+		response.Fail(c, nil, "未绑定邮箱")
+		return
+	}
+	if user.(model.User).EmailVerified {
+		response.Fail(c, nil, "邮箱已验证")
+		return
+	}
+	if err := e.sendVerification(user.(model.User).ID, *user.(model.User).Email); err != nil {
+		response.Fail(c, nil, "验证邮件发送失败")
+		return
+	}
+	response.Success(c, nil, "验证邮件已发送")
+}
+
+// Verify 处理验证邮件中的链接，链接中的邮箱与用户当前的邮箱一致时标记为已验证。
+func (e EmailController) Verify(c *gin.Context) {
+	userId, email, err := service.ParseSignedToken(service.LinkVerifyEmail, c.Query("token"))
+	if err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	var user model.User
+	if e.DB.Where("id = ?", userId).First(&user).RecordNotFound() || user.Email == nil || *user.Email != email {
+		response.Fail(c, nil, service.ErrLinkInvalid.Error())
+		return
+	}
+	if !user.EmailVerified {
+		if err := service.MarkEmailVerified(e.DB, user.ID, email); err != nil {
+			if err == service.ErrEmailTaken {
+				response.Fail(c, nil, "该邮箱已被其他账号验证")
+				return
+			}
+			response.Fail(c, nil, "验证失败")
+			return
+		}
+		service.Audit(e.DB, c, user.ID, model.AuditEmailVerify, "user", strconv.Itoa(int(user.ID)), nil, gin.H{"email": email})
+	}
+	response.Success(c, nil, "邮箱验证成功")
+}
+
+// ForgotPassword 向已验证的邮箱发送重置密码邮件。邮箱不存在时同样返回成功，
+// 邮件在后台发送，使响应时间与邮箱是否注册无关，避免借此探测邮箱是否注册。
+func (e EmailController) ForgotPassword(c *gin.Context) {
+	var request vo.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	var user model.User
+	e.DB.Where("email = ? AND email_verified = ?", strings.ToLower(strings.TrimSpace(request.Email)), true).First(&user)
+	if user.ID != 0 {
+		// 令牌中包含 token 版本，重置密码后版本递增，链接随之失效，因此只能使用一次
+		token := service.NewSignedToken(service.LinkResetEmail, user.ID, strconv.Itoa(user.TokenVersion), emailResetTTL)
+		body := "您正在重置密码，请在 " + strconv.Itoa(int(emailResetTTL.Minutes())) + " 分钟内打开以下链接设置新密码：\n\n" +
+			e.WebURL + "/reset-password?token=" + url.QueryEscape(token) + "\n\n如果不是您本人操作，请忽略这封邮件。"
+		go func(to string) {
+			if err := service.GetMailer().Send(to, "重置密码", body); err != nil {
+				log.Println("send reset email failed:", err)
+			}
+		}(*user.Email)
+	}
+	response.Success(c, nil, "如果该邮箱已绑定账号，重置密码邮件将很快送达")
+}
+
+// ResetPassword 使用重置密码邮件中的令牌设置新密码，之前发放的 token 全部失效。
+func (e EmailController) ResetPassword(c *gin.Context) {
+	var request vo.ResetPasswordByEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	userId, version, err := service.ParseSignedToken(service.LinkResetEmail, request.Token)
+	if err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	var user model.User
+	if e.DB.Where("id = ?", userId).First(&user).RecordNotFound() || strconv.Itoa(user.TokenVersion) != version {
+		response.Fail(c, nil, service.ErrLinkInvalid.Error())
+		return
+	}
+	if err := service.CheckPassword(request.NewPassword, user.PhoneNumber, user.UserName); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	if _, err := service.SetPassword(e.DB, user, request.NewPassword); err != nil {
+		response.Fail(c, nil, "重置失败")
+		return
+	}
+	// 重置密码后解除因登录失败产生的锁定
+	service.LoginSucceeded(service.LoginKey(user.ID, ""))
+	service.Audit(e.DB, c, user.ID, model.AuditPasswordReset, "user", strconv.Itoa(int(user.ID)), nil, gin.H{"via": "email"})
+	response.Success(c, nil, "重置成功，请重新登录")
+}
+
+// sendVerification 发送邮箱验证邮件。
+func (e EmailController) sendVerification(userId uint, email string) error {
+	token := service.NewSignedToken(service.LinkVerifyEmail, userId, email, emailVerifyTTL)
+	body := "请在 " + strconv.Itoa(int(emailVerifyTTL.Hours())) + " 小时内打开以下链接验证您的邮箱：\n\n" +
+		e.BaseURL + "/email/verify?token=" + url.QueryEscape(token) + "\n\n如果不是您本人操作，请忽略这封邮件。"
+	if err := service.GetMailer().Send(email, "验证您的邮箱", body); err != nil {
+		log.Println("send verification email failed:", err)
+		return err
+	}
+	return nil
+}
+
+// NewEmailController 函数用于创建并初始化 EmailController 实例。
+func NewEmailController() IEmailController {
+	baseURL, webURL := siteURLs()
+	return &EmailController{DB: common.GetDB(), BaseURL: baseURL, WebURL: webURL}
+}
+
+// siteURLs 返回邮件和跳转中使用的地址：服务端地址由环境变量 BASE_URL 配置，
+// 前端地址由 WEB_URL 配置，未配置时与服务端地址相同。
+func siteURLs() (string, string) {
+	baseURL := strings.TrimRight(os.Getenv("BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	webURL := strings.TrimRight(os.Getenv("WEB_URL"), "/")
+	if webURL == "" {
+		webURL = baseURL
+	}
+	return baseURL, webURL
+}
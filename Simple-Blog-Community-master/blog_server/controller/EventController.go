@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"io"
+	"strconv"
+	"time"
+)
+
+// EventController.go
+
+// heartbeatInterval 是实时推送连接发送心跳的间隔，防止连接被代理服务器断开。
+const heartbeatInterval = 25 * time.Second
+
+// EventTicket 签发建立实时推送连接的一次性票据。EventSource 无法设置请求头，
+// 客户端先携带 Authorization 请求头获取票据，再通过 /events?ticket=<票据> 建立连接，避免 token 出现在地址和访问日志中。
+func EventTicket(c *gin.Context) {
+	ticket, err := service.IssueStreamTicket(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		response.Fail(c, nil, "签发票据失败")
+		return
+	}
+	response.Success(c, gin.H{"ticket": ticket, "expires_in": int(service.StreamTicketTTL.Seconds())}, "签发成功")
+}
+
+// Events 通过 Server-Sent Events 向当前登录用户实时推送事件。
+// 客户端重连时携带 Last-Event-ID 请求头（或 lastEventId 查询参数）即可补发断线期间的事件。
+func Events(c *gin.Context) {
+	user, _ := c.Get("user")
+	lastEventId, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if lastEventId == 0 {
+		lastEventId, _ = strconv.ParseUint(c.Query("lastEventId"), 10, 64)
+	}
+	var sessionId string
+	var tokenId uint
+	if session, ok := c.Get("session"); ok {
+		sessionId = session.(model.Session).ID
+	}
+	if token, ok := c.Get("access_token"); ok {
+		tokenId = token.(model.AccessToken).ID
+	}
+	hub := service.GetHub()
+	sub, replay, resync := hub.Subscribe(user.(model.User).ID, sessionId, tokenId, lastEventId)
+	defer hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// 告知客户端断线后的重连间隔，并补发断线期间的事件
+	c.Render(-1, sse.Event{Event: "ready", Retry: 3000, Data: gin.H{"resync": resync}})
+	for _, event := range replay {
+		c.Render(-1, sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: event.Type, Data: event.Data})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-sub.Events:
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: event.Type, Data: event.Data})
+			return true
+		case <-heartbeat.C:
+			// 连接可能保持很久，每次心跳时确认会话或令牌仍然有效，避免错过注销时的断开通知
+			if !streamAuthorized(user.(model.User).ID, sessionId, tokenId) {
+				c.Render(-1, sse.Event{Event: "revoked", Data: gin.H{}})
+				return false
+			}
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-sub.Revoked:
+			// 会话或令牌已注销，通知客户端不要重连
+			c.Render(-1, sse.Event{Event: "revoked", Data: gin.H{}})
+			return false
+		case <-sub.Dropped:
+			// 客户端处理过慢，断开连接，由客户端重连后补发
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// streamAuthorized 重新检查推送连接使用的会话或个人访问令牌是否仍然有效，以及用户是否已被封禁或注销。
+func streamAuthorized(userId uint, sessionId string, tokenId uint) bool {
+	db := common.GetDB()
+	var user model.User
+	if db.Where("id = ?", userId).First(&user).RecordNotFound() || user.IsSuspended() || user.IsClosed() {
+		return false
+	}
+	if tokenId != 0 {
+		var token model.AccessToken
+		db.Where("id = ?", tokenId).First(&token)
+		return token.ID != 0 && token.Active()
+	}
+	var session model.Session
+	db.Where("id = ? AND user_id = ?", sessionId, userId).First(&session)
+	return session.ID != "" && session.Active()
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxImportSize 是上传的导入文件允许的最大大小。
+const maxImportSize = 100 << 20
+
+// ImportController 结构体用于处理批量导入文章相关的请求。
+type ImportController struct {
+	DB *gorm.DB
+}
+
+// IImportController 接口定义了导入控制器需要实现的一系列方法。
+type IImportController interface {
+	List(c *gin.Context)   // 查询导入任务
+	Show(c *gin.Context)   // 查询导入任务中每篇文章的结果
+	Create(c *gin.Context) // 上传文件并创建导入任务
+}
+
+// List 查询当前用户最近的导入任务。
+func (i ImportController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	var jobs []model.ImportJob
+	i.DB.Where("user_id = ?", user.(model.User).ID).Order("id desc").Limit(20).Find(&jobs)
+	response.Success(c, gin.H{"jobs": jobs}, "查找成功")
+}
+
+// Show 查询导入任务及其中每篇文章的导入结果。
+func (i ImportController) Show(c *gin.Context) {
+	user, _ := c.Get("user")
+	var job model.ImportJob
+	if i.DB.Where("id = ? AND user_id = ?", c.Params.ByName("id"), user.(model.User).ID).First(&job).RecordNotFound() {
+		response.Fail(c, nil, "导入任务不存在")
+		return
+	}
+	var items []model.ImportItem
+	i.DB.Where("job_id = ?", job.ID).Order("id").Find(&items)
+	response.Success(c, gin.H{"job": job, "items": items}, "查找成功")
+}
+
+// Create 上传导入文件并创建导入任务，文章在后台导入，完成后通过站内通知告知用户。
+// file 为 Markdown 文件的 ZIP 压缩包或 WordPress 导出的 XML 文件，
+// category_id 为无法匹配分类的文章使用的默认分类。
+func (i ImportController) Create(c *gin.Context) {
+	user, _ := c.Get("user")
+	categoryId, _ := strconv.Atoi(c.PostForm("category_id"))
+	if i.DB.Where("id = ?", categoryId).First(&model.Category{}).RecordNotFound() {
+		response.Fail(c, nil, "请选择默认分类")
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.Fail(c, nil, "请上传导入文件")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportSize {
+		response.Fail(c, nil, "导入文件不能超过 100MB")
+		return
+	}
+	ext := strings.ToLower(path.Ext(header.Filename))
+	var source string
+	switch ext {
+	case ".zip":
+		source = model.ImportMarkdown
+	case ".xml":
+		source = model.ImportWordPress
+	default:
+		response.Fail(c, nil, "只支持 Markdown 文件的 ZIP 压缩包或 WordPress 导出的 XML 文件")
+		return
+	}
+	var count int
+	i.DB.Model(&model.ImportJob{}).Where("user_id = ? AND status IN (?)", user.(model.User).ID,
+		[]string{model.ImportPending, model.ImportRunning}).Count(&count)
+	if count > 0 {
+		response.Fail(c, nil, "已有正在进行的导入，请等待完成后再试")
+		return
+	}
+	saved, err := service.SaveImportFile(file, ext)
+	if err != nil {
+		response.Fail(c, nil, "上传失败")
+		return
+	}
+	job := model.ImportJob{
+		UserId:     user.(model.User).ID,
+		Source:     source,
+		FileName:   header.Filename,
+		File:       saved,
+		CategoryId: uint(categoryId),
+		Status:     model.ImportPending,
+	}
+	if err := i.DB.Create(&job).Error; err != nil {
+		os.Remove(saved)
+		response.Fail(c, nil, "上传失败")
+		return
+	}
+	service.QueueImport()
+	response.Success(c, gin.H{"job": job}, "已开始导入，完成后会通知你")
+}
+
+// NewImportController 函数用于创建并初始化 ImportController 实例。
+func NewImportController() IImportController {
+	db := common.GetDB()
+	db.AutoMigrate(model.ImportJob{}, model.ImportItem{})
+	return &ImportController{DB: db}
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"strings"
+)
+
+// LikeController 结构体用于处理文章点赞相关的请求。
+type LikeController struct {
+	DB *gorm.DB
+}
+
+// ILikeController 接口定义了点赞控制器需要实现的一系列方法。
+type ILikeController interface {
+	Liked(c *gin.Context)  // 查询是否已点赞
+	Like(c *gin.Context)   // 点赞文章
+	UnLike(c *gin.Context) // 取消点赞
+	List(c *gin.Context)   // 查询用户点赞过的文章
+}
+
+// Liked 查询当前登录用户是否已点赞指定文章，并返回文章的点赞数。
+func (l LikeController) Liked(c *gin.Context) {
+	articleId := c.Params.ByName("id")
+	var article model.Article
+	if l.DB.Where("id = ?", articleId).First(&article).RecordNotFound() {
+		response.Fail(c, nil, "文章不存在")
+		return
+	}
+	user, _ := c.Get("user")
+	var count int
+	l.DB.Model(model.Like{}).Where("user_id = ? AND article_id = ?", user.(model.User).ID, articleId).Count(&count)
+	response.Success(c, gin.H{"liked": count > 0, "count": article.LikeCount}, "查询成功")
+}
+
+// Like 点赞文章，同一用户对同一篇文章只能点赞一次。
+func (l LikeController) Like(c *gin.Context) {
+	articleId := c.Params.ByName("id")
+	var article model.Article
+	if l.DB.Where("id = ?", articleId).First(&article).RecordNotFound() {
+		response.Fail(c, nil, "文章不存在")
+		return
+	}
+	user, _ := c.Get("user")
+	if !canViewArticle(l.DB, article, user) {
+		response.Fail(c, nil, "文章不存在")
+		return
+	}
+	// 被文章作者屏蔽时不能点赞
+	if service.IsBlocked(l.DB, article.UserId, user.(model.User).ID) {
+		response.Fail(c, nil, "对方已将你屏蔽")
+		return
+	}
+	like := model.Like{UserId: user.(model.User).ID, ArticleId: articleId}
+	if !l.DB.Where(&like).First(&model.Like{}).RecordNotFound() {
+		response.Fail(c, nil, "已点赞")
+		return
+	}
+	// 新增点赞记录并更新点赞数
+	tx := l.DB.Begin()
+	if err := tx.Create(&like).Error; err != nil {
+		tx.Rollback()
+		// 并发点赞时由唯一索引拒绝重复的记录
+		if strings.Contains(err.Error(), "Duplicate") && strings.Contains(err.Error(), "idx_like_user_article") {
+			response.Fail(c, nil, "已点赞")
+			return
+		}
+		response.Fail(c, nil, "点赞失败")
+		return
+	}
+	if err := tx.Model(&article).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "点赞失败")
+		return
+	}
+	tx.Commit()
+	// 通知文章作者
+	service.Notify(l.DB, article.UserId, user.(model.User).ID, model.NotifyLike, articleId, user.(model.User).UserName+" 赞了你的文章《"+article.Title+"》")
+	response.Success(c, gin.H{"count": article.LikeCount + 1}, "点赞成功")
+}
+
+// UnLike 取消点赞
+func (l LikeController) UnLike(c *gin.Context) {
+	articleId := c.Params.ByName("id")
+	user, _ := c.Get("user")
+	var like model.Like
+	if l.DB.Where("user_id = ? AND article_id = ?", user.(model.User).ID, articleId).First(&like).RecordNotFound() {
+		response.Fail(c, nil, "尚未点赞")
+		return
+	}
+	// 删除点赞记录并更新点赞数，并发取消时只有真正删除了记录的请求减少点赞数
+	tx := l.DB.Begin()
+	result := tx.Delete(&like)
+	if result.Error != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "取消失败")
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		response.Fail(c, nil, "尚未点赞")
+		return
+	}
+	if err := tx.Model(model.Article{}).Where("id = ? AND like_count > 0", articleId).
+		UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "取消失败")
+		return
+	}
+	tx.Commit()
+	response.Success(c, nil, "取消成功")
+}
+
+// List 分页查询指定用户点赞过的文章，按点赞时间倒序排列。
+func (l LikeController) List(c *gin.Context) {
+	userId := c.Params.ByName("id")
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "5"))
+	var articles []model.ArticleInfo
+	var count int
+	// 总数与列表使用相同的条件，不计入已隐藏或已删除的文章
+	query := l.DB.Table("articles").Joins("JOIN likes ON likes.article_id = articles.id").
+		Where("likes.user_id = ?", userId).Where(visibleArticles("articles"))
+	query.Select(articleInfoFields("articles")).Order("likes.created_at desc").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&articles)
+	query.Count(&count)
+	user, _ := c.Get("user")
+	markLiked(l.DB, user, articles)
+	response.Success(c, gin.H{"article": articles, "count": count}, "查找成功")
+}
+
+// markLiked 为文章列表填充当前登录用户的点赞状态，未登录时不做处理。
+func markLiked(db *gorm.DB, user interface{}, articles []model.ArticleInfo) {
+	if user == nil || len(articles) == 0 {
+		return
+	}
+	ids := make([]string, len(articles))
+	for i := range articles {
+		ids[i] = articles[i].ID
+	}
+	var liked []string
+	db.Model(model.Like{}).Where("user_id = ? AND article_id IN (?)", user.(model.User).ID, ids).Pluck("article_id", &liked)
+	set := make(map[string]bool, len(liked))
+	for _, id := range liked {
+		set[id] = true
+	}
+	for i := range articles {
+		articles[i].Liked = set[articles[i].ID]
+	}
+}
+
+// NewLikeController 函数用于创建并初始化 LikeController 实例。
+func NewLikeController() ILikeController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Like{})
+	return &LikeController{DB: db}
+}
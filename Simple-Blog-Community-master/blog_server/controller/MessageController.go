@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 私信的长度与频率限制
+const (
+	maxMessageLength       = 1000 // 单条私信的最大字数
+	maxMessagesPerMinute   = 10   // 每分钟最多发送的私信数
+	maxMessagesPerDay      = 300  // 每天最多发送的私信数
+	maxConversationsPerDay = 20   // 每天最多主动发起的新会话数
+)
+
+// MessageController 结构体用于处理私信相关的请求。
+type MessageController struct {
+	DB *gorm.DB
+}
+
+// IMessageController 接口定义了私信控制器需要实现的一系列方法。
+type IMessageController interface {
+	Conversations(c *gin.Context) // 查询会话列表
+	Messages(c *gin.Context)      // 查询与指定用户的私信
+	Send(c *gin.Context)          // 发送私信
+	Read(c *gin.Context)          // 将与指定用户的私信标记为已读
+}
+
+// Conversations 分页查询当前登录用户的会话列表，包含对方用户信息、最后一条私信和未读数。
+func (m MessageController) Conversations(c *gin.Context) {
+	user, _ := c.Get("user")
+	userId := user.(model.User).ID
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	var conversations []model.Conversation
+	var count, unread int
+	query := m.DB.Model(model.Conversation{}).Where("user_a = ? OR user_b = ?", userId, userId)
+	query.Order("updated_at desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&conversations)
+	query.Count(&count)
+	m.DB.Model(model.Message{}).Where("receiver_id = ? AND read_at IS NULL", userId).Count(&unread)
+
+	infos := make([]model.ConversationInfo, 0, len(conversations))
+	for _, conversation := range conversations {
+		otherId := conversation.UserA
+		if otherId == userId {
+			otherId = conversation.UserB
+		}
+		info := model.ConversationInfo{ID: conversation.ID, UpdatedAt: conversation.UpdatedAt}
+		m.DB.Table("users").Select("id, avatar, user_name").Where("id = ?", otherId).Scan(&info.User)
+		var last model.Message
+		if !m.DB.Where("id = ?", conversation.LastMessageId).First(&last).RecordNotFound() {
+			info.LastMessage = &last
+		}
+		m.DB.Model(model.Message{}).Where("conversation_id = ? AND receiver_id = ? AND read_at IS NULL", conversation.ID, userId).
+			Count(&info.Unread)
+		infos = append(infos, info)
+	}
+	response.Success(c, gin.H{"conversations": infos, "count": count, "unread": unread}, "查找成功")
+}
+
+// Messages 分页查询当前登录用户与指定用户之间的私信，按时间倒序排列。
+func (m MessageController) Messages(c *gin.Context) {
+	user, _ := c.Get("user")
+	otherId, _ := strconv.Atoi(c.Params.ByName("id"))
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	messages := []model.Message{}
+	var count int
+	conversation, found := m.findConversation(user.(model.User).ID, uint(otherId))
+	if found {
+		query := m.DB.Model(model.Message{}).Where("conversation_id = ?", conversation.ID)
+		query.Order("id desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&messages)
+		query.Count(&count)
+	}
+	response.Success(c, gin.H{"messages": messages, "count": count}, "查找成功")
+}
+
+// Send 向指定用户发送私信。被对方屏蔽、屏蔽了对方或发送过于频繁时拒绝发送。
+func (m MessageController) Send(c *gin.Context) {
+	var request vo.SendMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	content := strings.TrimSpace(request.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxMessageLength {
+		response.Fail(c, nil, "私信内容不能为空且不能超过1000字")
+		return
+	}
+	user, _ := c.Get("user")
+	sender := user.(model.User)
+	var receiver model.User
+	if m.DB.Where("id = ?", c.Params.ByName("id")).First(&receiver).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	if receiver.ID == sender.ID {
+		response.Fail(c, nil, "不能给自己发私信")
+		return
+	}
+	if service.IsBlocked(m.DB, receiver.ID, sender.ID) {
+		response.Fail(c, nil, "对方已将你屏蔽")
+		return
+	}
+	if service.IsBlocked(m.DB, sender.ID, receiver.ID) {
+		response.Fail(c, nil, "你已屏蔽对方")
+		return
+	}
+	conversation, found := m.findConversation(sender.ID, receiver.ID)
+	if msg := m.checkRate(sender.ID, !found); msg != "" {
+		response.Fail(c, nil, msg)
+		return
+	}
+
+	tx := m.DB.Begin()
+	if !found {
+		conversation.UserA, conversation.UserB = sortPair(sender.ID, receiver.ID)
+		if err := tx.Create(&conversation).Error; err != nil {
+			tx.Rollback()
+			response.Fail(c, nil, "发送失败")
+			return
+		}
+	}
+	message := model.Message{
+		ConversationId: conversation.ID,
+		SenderId:       sender.ID,
+		ReceiverId:     receiver.ID,
+		Content:        content,
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "发送失败")
+		return
+	}
+	if err := tx.Model(&conversation).Updates(map[string]interface{}{
+		"last_message_id": message.ID,
+		"updated_at":      time.Now(),
+	}).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "发送失败")
+		return
+	}
+	tx.Commit()
+	// 实时推送给接收者
+	service.GetHub().Publish(receiver.ID, "message", message)
+	response.Success(c, gin.H{"message": message}, "发送成功")
+}
+
+// Read 将指定用户发给当前登录用户的私信全部标记为已读，并通知发送者。
+func (m MessageController) Read(c *gin.Context) {
+	user, _ := c.Get("user")
+	userId := user.(model.User).ID
+	otherId, _ := strconv.Atoi(c.Params.ByName("id"))
+	conversation, found := m.findConversation(userId, uint(otherId))
+	if !found {
+		response.Fail(c, nil, "会话不存在")
+		return
+	}
+	now := time.Now()
+	if err := m.DB.Model(model.Message{}).
+		Where("conversation_id = ? AND receiver_id = ? AND read_at IS NULL", conversation.ID, userId).
+		Update("read_at", now).Error; err != nil {
+		response.Fail(c, nil, "更新失败")
+		return
+	}
+	// 已读回执
+	service.GetHub().Publish(uint(otherId), "message_read", gin.H{
+		"conversation_id": conversation.ID,
+		"reader_id":       userId,
+		"read_at":         model.Time(now),
+	})
+	response.Success(c, nil, "更新成功")
+}
+
+// findConversation 查询两个用户之间的会话。
+func (m MessageController) findConversation(userId, otherId uint) (model.Conversation, bool) {
+	var conversation model.Conversation
+	a, b := sortPair(userId, otherId)
+	found := !m.DB.Where("user_a = ? AND user_b = ?", a, b).First(&conversation).RecordNotFound()
+	return conversation, found
+}
+
+// checkRate 检查用户发送私信的频率，超出限制时返回提示信息。
+func (m MessageController) checkRate(userId uint, newConversation bool) string {
+	now := time.Now()
+	var count int
+	m.DB.Model(model.Message{}).Where("sender_id = ? AND created_at > ?", userId, now.Add(-time.Minute)).Count(&count)
+	if count >= maxMessagesPerMinute {
+		return "发送过于频繁，请稍后再试"
+	}
+	m.DB.Model(model.Message{}).Where("sender_id = ? AND created_at > ?", userId, now.Add(-24*time.Hour)).Count(&count)
+	if count >= maxMessagesPerDay {
+		return "今日发送的私信已达上限"
+	}
+	if newConversation {
+		// 统计当天由该用户发出第一条私信的会话数
+		m.DB.Table("conversations").
+			Joins("JOIN messages ON messages.conversation_id = conversations.id").
+			Where("conversations.created_at > ? AND messages.sender_id = ?", now.Add(-24*time.Hour), userId).
+			Where("messages.id = (SELECT MIN(id) FROM messages WHERE conversation_id = conversations.id)").
+			Count(&count)
+		if count >= maxConversationsPerDay {
+			return "今日发起的会话已达上限"
+		}
+	}
+	return ""
+}
+
+// sortPair 将两个用户 ID 按从小到大排列。
+func sortPair(a, b uint) (uint, uint) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// NewMessageController 函数用于创建并初始化 MessageController 实例。
+func NewMessageController() IMessageController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Conversation{}, model.Message{})
+	return &MessageController{DB: db}
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+)
+
+// MuteController 结构体用于处理静音用户相关的请求。
+// 静音只影响自己看到的内容：被静音用户的文章不再出现在你的文章列表和动态中。
+type MuteController struct {
+	DB *gorm.DB
+}
+
+// IMuteController 接口定义了静音控制器需要实现的一系列方法。
+type IMuteController interface {
+	List(c *gin.Context)   // 查询静音列表
+	Mute(c *gin.Context)   // 静音用户
+	UnMute(c *gin.Context) // 取消静音
+}
+
+// List 查询当前登录用户静音的用户列表。
+func (m MuteController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	var users []model.UserInfo
+	m.DB.Table("users").Select("users.id, users.avatar, users.user_name").
+		Joins("JOIN mutes ON mutes.muted_id = users.id").
+		Where("mutes.user_id = ?", user.(model.User).ID).Order("mutes.created_at desc").Scan(&users)
+	response.Success(c, gin.H{"users": users}, "查找成功")
+}
+
+// Mute 静音指定用户。
+func (m MuteController) Mute(c *gin.Context) {
+	user, _ := c.Get("user")
+	mutedId, _ := strconv.Atoi(c.Params.ByName("id"))
+	if uint(mutedId) == user.(model.User).ID {
+		response.Fail(c, nil, "不能静音自己")
+		return
+	}
+	var muted model.User
+	if m.DB.Where("id = ?", mutedId).First(&muted).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	mute := model.Mute{UserId: user.(model.User).ID, MutedId: muted.ID}
+	if !m.DB.Where(&mute).First(&model.Mute{}).RecordNotFound() {
+		response.Fail(c, nil, "已静音")
+		return
+	}
+	if err := m.DB.Create(&mute).Error; err != nil {
+		response.Fail(c, nil, "静音失败")
+		return
+	}
+	response.Success(c, nil, "静音成功")
+}
+
+// UnMute 取消静音指定用户。
+func (m MuteController) UnMute(c *gin.Context) {
+	user, _ := c.Get("user")
+	if err := m.DB.Where("user_id = ? AND muted_id = ?", user.(model.User).ID, c.Params.ByName("id")).
+		Delete(model.Mute{}).Error; err != nil {
+		response.Fail(c, nil, "取消失败")
+		return
+	}
+	response.Success(c, nil, "取消成功")
+}
+
+// NewMuteController 函数用于创建并初始化 MuteController 实例。
+func NewMuteController() IMuteController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Mute{})
+	return &MuteController{DB: db}
+}
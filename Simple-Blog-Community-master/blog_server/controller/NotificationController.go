@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+)
+
+// NotificationController 结构体用于处理站内通知相关的请求。
+type NotificationController struct {
+	DB *gorm.DB
+}
+
+// INotificationController 接口定义了通知控制器需要实现的一系列方法。
+type INotificationController interface {
+	List(c *gin.Context)              // 查询通知列表
+	Unread(c *gin.Context)            // 查询未读通知数
+	Read(c *gin.Context)              // 将单条通知标记为已读
+	ReadAll(c *gin.Context)           // 将全部通知标记为已读
+	Preferences(c *gin.Context)       // 查询通知偏好设置
+	UpdatePreferences(c *gin.Context) // 修改通知偏好设置
+}
+
+// List 分页查询当前登录用户的通知，按时间倒序排列，unread=true 时只返回未读通知。
+func (n NotificationController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	userId := user.(model.User).ID
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	query := n.DB.Table("notifications").Where("notifications.user_id = ?", userId)
+	if c.Query("unread") == "true" {
+		query = query.Where("notifications.is_read = ?", false)
+	}
+	var notifications []model.NotificationInfo
+	var count, unread int
+	query.Select("notifications.id, notifications.actor_id, users.user_name AS actor_name, users.avatar AS actor_avatar, " +
+		"notifications.type, notifications.target_id, notifications.content, notifications.is_read, notifications.created_at").
+		Joins("LEFT JOIN users ON users.id = notifications.actor_id").
+		Order("notifications.created_at desc, notifications.id desc").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).Scan(&notifications)
+	query.Count(&count)
+	n.DB.Model(model.Notification{}).Where("user_id = ? AND is_read = ?", userId, false).Count(&unread)
+	response.Success(c, gin.H{"notifications": notifications, "count": count, "unread": unread}, "查找成功")
+}
+
+// Unread 查询当前登录用户的未读通知总数以及各类型的未读数。
+func (n NotificationController) Unread(c *gin.Context) {
+	user, _ := c.Get("user")
+	var rows []struct {
+		Type  string
+		Count int
+	}
+	n.DB.Table("notifications").Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", user.(model.User).ID, false).Group("type").Scan(&rows)
+	total := 0
+	types := gin.H{}
+	for _, row := range rows {
+		types[row.Type] = row.Count
+		total += row.Count
+	}
+	response.Success(c, gin.H{"unread": total, "types": types}, "查询成功")
+}
+
+// Read 将当前登录用户的单条通知标记为已读。
+func (n NotificationController) Read(c *gin.Context) {
+	user, _ := c.Get("user")
+	var notification model.Notification
+	if n.DB.Where("id = ? AND user_id = ?", c.Params.ByName("id"), user.(model.User).ID).First(&notification).RecordNotFound() {
+		response.Fail(c, nil, "通知不存在")
+		return
+	}
+	if err := n.DB.Model(&notification).Update("is_read", true).Error; err != nil {
+		response.Fail(c, nil, "更新失败")
+		return
+	}
+	response.Success(c, nil, "更新成功")
+}
+
+// ReadAll 将当前登录用户的全部通知标记为已读。
+func (n NotificationController) ReadAll(c *gin.Context) {
+	user, _ := c.Get("user")
+	if err := n.DB.Model(model.Notification{}).Where("user_id = ? AND is_read = ?", user.(model.User).ID, false).
+		Update("is_read", true).Error; err != nil {
+		response.Fail(c, nil, "更新失败")
+		return
+	}
+	response.Success(c, nil, "更新成功")
+}
+
+// Preferences 查询当前登录用户各类型通知是否被屏蔽。
+func (n NotificationController) Preferences(c *gin.Context) {
+	user, _ := c.Get("user")
+	var muted []string
+	n.DB.Model(model.NotificationPreference{}).Where("user_id = ?", user.(model.User).ID).Pluck("type", &muted)
+	preferences := gin.H{}
+	for _, kind := range model.NotificationTypes {
+		preferences[kind] = false
+	}
+	for _, kind := range muted {
+		preferences[kind] = true
+	}
+	response.Success(c, gin.H{"muted": preferences}, "查询成功")
+}
+
+// UpdatePreferences 修改当前登录用户的通知偏好设置，请求体为通知类型到是否屏蔽的映射。
+func (n NotificationController) UpdatePreferences(c *gin.Context) {
+	var request map[string]bool
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	for kind := range request {
+		if !service.IsNotificationType(kind) {
+			response.Fail(c, nil, "通知类型错误")
+			return
+		}
+	}
+	user, _ := c.Get("user")
+	userId := user.(model.User).ID
+	tx := n.DB.Begin()
+	for kind, muted := range request {
+		err := tx.Where("user_id = ? AND type = ?", userId, kind).Delete(model.NotificationPreference{}).Error
+		if err == nil && muted {
+			err = tx.Create(&model.NotificationPreference{UserId: userId, Type: kind}).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			response.Fail(c, nil, "更新失败")
+			return
+		}
+	}
+	tx.Commit()
+	response.Success(c, nil, "更新成功")
+}
+
+// NewNotificationController 函数用于创建并初始化 NotificationController 实例。
+func NewNotificationController() INotificationController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Notification{}, model.NotificationPreference{})
+	return &NotificationController{DB: db}
+}
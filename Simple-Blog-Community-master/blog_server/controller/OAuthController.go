@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	oauthStateTTL    = 10 * time.Minute // 从跳转到外部登录服务到回调的最长时间
+	oauthNonceCookie = "oauth_nonce"    // 将 state 与发起登录的浏览器绑定的 cookie
+)
+
+// OAuthController 结构体用于处理外部登录服务（OAuth2 / OpenID Connect）相关的请求。
+type OAuthController struct {
+	DB      *gorm.DB
+	BaseURL string // 服务端地址，用于生成回调地址
+	WebURL  string // 前端地址，登录完成后跳转到 WebURL/oauth/callback
+}
+
+// IOAuthController 接口定义了外部登录控制器需要实现的一系列方法。
+type IOAuthController interface {
+	Providers(c *gin.Context)  // 查询可用的外部登录服务
+	Start(c *gin.Context)      // 跳转到外部登录服务
+	Callback(c *gin.Context)   // 外部登录服务的回调
+	Link(c *gin.Context)       // 生成绑定外部登录账号的地址
+	Confirm(c *gin.Context)    // 确认绑定外部登录账号
+	Identities(c *gin.Context) // 查询已绑定的外部登录账号
+	Unlink(c *gin.Context)     // 解绑外部登录账号
+}
+
+// Providers 查询可用的外部登录服务。
+func (o OAuthController) Providers(c *gin.Context) {
+	providers := make([]gin.H, 0)
+	for _, p := range service.OAuthProviders() {
+		providers = append(providers, gin.H{
+			"name":         p.Name,
+			"display_name": p.DisplayName,
+			"login_url":    o.BaseURL + "/oauth/" + p.Name + "/login",
+		})
+	}
+	response.Success(c, gin.H{"providers": providers}, "查找成功")
+}
+
+// Start 跳转到外部登录服务的授权页面，需要由浏览器直接访问。
+// 携带 link 参数时表示已登录用户绑定外部账号，link 由 Link 接口生成，回调后还需要通过 Confirm 接口确认。
+func (o OAuthController) Start(c *gin.Context) {
+	provider, err := service.GetOAuthProvider(c.Param("provider"))
+	if err != nil {
+		o.finish(c, url.Values{"error": {err.Error()}})
+		return
+	}
+	var userId uint
+	if link := c.Query("link"); link != "" {
+		var value string
+		userId, value, err = service.ParseSignedToken(service.LinkOAuthBind, link)
+		if err != nil || value != provider.Name {
+			o.finish(c, url.Values{"error": {service.ErrLinkInvalid.Error()}})
+			return
+		}
+	}
+	// state 中包含 PKCE 的 code_verifier 和 nonce 的哈希值，nonce 保存在 cookie 中，
+	// 回调时校验两者一致，防止攻击者让其他人的浏览器完成自己发起的登录
+	nonce := randomString(16)
+	verifier := service.NewPKCEVerifier()
+	state := service.NewSignedToken(service.LinkOAuthState, userId, provider.Name+":"+verifier+":"+hashNonce(nonce), oauthStateTTL)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthNonceCookie, nonce, int(oauthStateTTL.Seconds()), "/oauth", "", strings.HasPrefix(o.BaseURL, "https"), true)
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(o.redirectURI(provider.Name), state, verifier))
+}
+
+// Callback 处理外部登录服务的回调：绑定外部账号，或者登录（首次登录时创建账号），
+// 完成后跳转到前端，结果通过 URL 片段传递，避免 token 出现在服务器日志中。
+func (o OAuthController) Callback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		o.finish(c, url.Values{"error": {"授权失败：" + e}})
+		return
+	}
+	userId, value, err := service.ParseSignedToken(service.LinkOAuthState, c.Query("state"))
+	parts := strings.Split(value, ":")
+	nonce, _ := c.Cookie(oauthNonceCookie)
+	if err != nil || len(parts) != 3 || parts[0] != c.Param("provider") || nonce == "" || hashNonce(nonce) != parts[2] {
+		o.finish(c, url.Values{"error": {"登录已过期，请重试"}})
+		return
+	}
+	c.SetCookie(oauthNonceCookie, "", -1, "/oauth", "", false, true)
+	provider, err := service.GetOAuthProvider(parts[0])
+	if err != nil {
+		o.finish(c, url.Values{"error": {err.Error()}})
+		return
+	}
+	external, err := provider.Exchange(c.Query("code"), o.redirectURI(provider.Name), parts[1])
+	if err != nil {
+		log.Println("oauth exchange failed:", err)
+		o.finish(c, url.Values{"error": {"获取外部账号信息失败"}})
+		return
+	}
+
+	var identity model.ExternalIdentity
+	o.DB.Where("provider = ? AND subject = ?", provider.Name, external.Subject).First(&identity)
+
+	// 绑定外部账号。link 参数出现在地址中，可能被诱导他人的浏览器访问，使其外部账号绑定到攻击者的账号上，
+	// 因此这里只返回待确认的令牌，由前端携带当前登录用户的 token 调用 Confirm 接口完成绑定
+	if userId != 0 {
+		if identity.ID != 0 && identity.UserId != userId {
+			o.finish(c, url.Values{"error": {"该外部账号已绑定其他用户"}})
+			return
+		}
+		if identity.ID != 0 {
+			o.finish(c, url.Values{"linked": {provider.Name}})
+			return
+		}
+		value := strings.Join([]string{provider.Name, encodeClaim(external.Subject), encodeClaim(external.Email)}, ":")
+		o.finish(c, url.Values{"confirm_link": {provider.Name}, "confirm": {service.NewSignedToken(service.LinkOAuthLink, userId, value, oauthStateTTL)}})
+		return
+	}
+
+	// 登录：已绑定的外部账号直接登录，否则按已验证的邮箱关联已有用户，都没有时创建新用户
+	var user model.User
+	if identity.ID != 0 {
+		o.DB.Where("id = ?", identity.UserId).First(&user)
+	} else if external.Email != "" && external.EmailVerified {
+		o.DB.Where("email = ? AND email_verified = ?", external.Email, true).First(&user)
+		if user.ID != 0 {
+			if err := o.link(c, user.ID, provider.Name, external); err != nil {
+				o.finish(c, url.Values{"error": {"登录失败"}})
+				return
+			}
+		}
+	}
+	if user.ID == 0 {
+		if user, err = o.createUser(c, provider.Name, external); err != nil {
+			log.Println("oauth create user failed:", err)
+			o.finish(c, url.Values{"error": {"创建账号失败"}})
+			return
+		}
+	}
+	if user.IsSuspended() {
+		service.AuditThrottled(o.DB, c, service.SuspendedAuditWindow, user.ID, model.AuditSuspendedLogin, "user", strconv.Itoa(int(user.ID)), nil, gin.H{"provider": provider.Name})
+		o.finish(c, url.Values{"error": {service.SuspensionMessage(user)}})
+		return
+	}
+	// 开启了两步验证时同样需要通过 /login/2fa 提交动态验证码
+	if user.TOTPEnabled {
+		o.finish(c, url.Values{"two_factor": {"true"}, "challenge": {service.NewSignedToken(service.LinkTwoFactor, user.ID, strconv.Itoa(user.TokenVersion), twoFactorChallengeTTL)}})
+		return
+	}
+	token, err := service.StartSession(o.DB, c, user)
+	if err != nil {
+		o.finish(c, url.Values{"error": {"系统异常"}})
+		return
+	}
+	service.Audit(o.DB, c, user.ID, model.AuditLogin, "user", strconv.Itoa(int(user.ID)), nil, gin.H{"provider": provider.Name})
+	o.finish(c, url.Values{"token": {token}})
+}
+
+// Link 为当前用户生成绑定外部账号的地址，前端跳转到该地址完成绑定。
+func (o OAuthController) Link(c *gin.Context) {
+	user, _ := c.Get("user")
+	provider, err := service.GetOAuthProvider(c.Param("provider"))
+	if err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	link := service.NewSignedToken(service.LinkOAuthBind, user.(model.User).ID, provider.Name, 5*time.Minute)
+	response.Success(c, gin.H{"url": o.BaseURL + "/oauth/" + provider.Name + "/login?link=" + url.QueryEscape(link)}, "请跳转到该地址完成绑定")
+}
+
+// Confirm 确认绑定外部登录账号。令牌由绑定流程的回调生成，只能由发起绑定的用户在登录状态下确认。
+func (o OAuthController) Confirm(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.ConfirmOAuthLinkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "参数错误")
+		return
+	}
+	userId, value, err := service.ParseSignedToken(service.LinkOAuthLink, request.Token)
+	parts := strings.Split(value, ":")
+	if err != nil || userId != user.(model.User).ID || len(parts) != 3 || parts[0] != c.Param("provider") {
+		response.Fail(c, nil, service.ErrLinkInvalid.Error())
+		return
+	}
+	subject, email := decodeClaim(parts[1]), decodeClaim(parts[2])
+	var identity model.ExternalIdentity
+	o.DB.Where("provider = ? AND subject = ?", parts[0], subject).First(&identity)
+	if identity.ID != 0 && identity.UserId != userId {
+		response.Fail(c, nil, "该外部账号已绑定其他用户")
+		return
+	}
+	if identity.ID == 0 {
+		if err := o.link(c, userId, parts[0], service.ExternalUser{Subject: subject, Email: email}); err != nil {
+			response.Fail(c, nil, "绑定失败")
+			return
+		}
+	}
+	response.Success(c, gin.H{"provider": parts[0]}, "绑定成功")
+}
+
+// Identities 查询当前用户绑定的外部登录账号。
+func (o OAuthController) Identities(c *gin.Context) {
+	user, _ := c.Get("user")
+	var identities []model.ExternalIdentity
+	o.DB.Where("user_id = ?", user.(model.User).ID).Order("id").Find(&identities)
+	response.Success(c, gin.H{"identities": identities}, "查找成功")
+}
+
+// Unlink 解绑外部登录账号。解绑后用户必须仍有其他登录方式：密码或另一个外部账号。
+func (o OAuthController) Unlink(c *gin.Context) {
+	user, _ := c.Get("user")
+	var identity model.ExternalIdentity
+	if o.DB.Where("user_id = ? AND provider = ?", user.(model.User).ID, c.Param("provider")).First(&identity).RecordNotFound() {
+		response.Fail(c, nil, "未绑定该外部账号")
+		return
+	}
+	var count int
+	o.DB.Model(&model.ExternalIdentity{}).Where("user_id = ?", user.(model.User).ID).Count(&count)
+	// 使用占位手机号的账号只能通过已验证的邮箱找回密码
+	canUsePassword := !user.(model.User).HasPlaceholderPhone() || user.(model.User).EmailVerified
+	if count <= 1 && !canUsePassword {
+		response.Fail(c, nil, "这是唯一的登录方式，请先绑定邮箱并设置密码")
+		return
+	}
+	if err := o.DB.Delete(&identity).Error; err != nil {
+		response.Fail(c, nil, "解绑失败")
+		return
+	}
+	service.Audit(o.DB, c, user.(model.User).ID, model.AuditOAuthUnlink, "user", strconv.Itoa(int(user.(model.User).ID)), gin.H{"provider": identity.Provider, "subject": identity.Subject}, nil)
+	response.Success(c, nil, "解绑成功")
+}
+
+// link 将外部账号绑定到用户。
+func (o OAuthController) link(c *gin.Context, userId uint, provider string, external service.ExternalUser) error {
+	identity := model.ExternalIdentity{UserId: userId, Provider: provider, Subject: external.Subject, Email: external.Email}
+	if err := o.DB.Create(&identity).Error; err != nil {
+		return err
+	}
+	service.Audit(o.DB, c, userId, model.AuditOAuthLink, "user", strconv.Itoa(int(userId)), nil, gin.H{"provider": provider, "subject": external.Subject})
+	return nil
+}
+
+// createUser 为首次登录的外部账号创建用户。手机号使用占位值，密码随机生成，
+// 外部登录服务验证过且未被其他用户验证的邮箱直接作为已验证的邮箱。
+func (o OAuthController) createUser(c *gin.Context, provider string, external service.ExternalUser) (model.User, error) {
+	name := strings.TrimSpace(external.Name)
+	if utf8.RuneCountInString(name) > 20 {
+		name = string([]rune(name)[:20])
+	}
+	if name == "" || service.GetFilter().Check(name).Action != "" {
+		name = "用户" + randomString(6)
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(randomString(32)), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, err
+	}
+	user := model.User{
+		UserName:    name,
+		PhoneNumber: model.PlaceholderPhonePrefix + randomString(14),
+		Password:    string(password),
+		Avatar:      "/images/default_avatar.png",
+		Collects:    model.Array{},
+		Following:   model.Array{},
+	}
+	if external.Email != "" && external.EmailVerified && !service.EmailTaken(o.DB, external.Email, 0) {
+		user.Email = &external.Email
+	}
+	tx := o.DB.Begin()
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
+		return user, err
+	}
+	if err := tx.Create(&model.ExternalIdentity{UserId: user.ID, Provider: provider, Subject: external.Subject, Email: external.Email}).Error; err != nil {
+		tx.Rollback()
+		return user, err
+	}
+	tx.Commit()
+	// 外部登录服务验证过的邮箱直接标记为已验证，并移除其他用户绑定但未验证的同一邮箱
+	if user.Email != nil {
+		if err := service.MarkEmailVerified(o.DB, user.ID, *user.Email); err == nil {
+			user.EmailVerified = true
+		} else {
+			o.DB.Model(&user).UpdateColumn("email", nil)
+			user.Email = nil
+		}
+	}
+	service.Audit(o.DB, c, user.ID, model.AuditRegister, "user", strconv.Itoa(int(user.ID)), nil, gin.H{"provider": provider, "subject": external.Subject})
+	return user, nil
+}
+
+// finish 跳转到前端的回调页面，结果放在 URL 片段中。
+func (o OAuthController) finish(c *gin.Context, result url.Values) {
+	c.Redirect(http.StatusFound, o.WebURL+"/oauth/callback#"+result.Encode())
+}
+
+// redirectURI 返回外部登录服务的回调地址，需要在外部登录服务中登记。
+func (o OAuthController) redirectURI(provider string) string {
+	return o.BaseURL + "/oauth/" + provider + "/callback"
+}
+
+// randomString 生成指定长度的随机小写字母和数字。
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return strings.ToLower(base32.StdEncoding.EncodeToString(b))[:n]
+}
+
+// encodeClaim 编码外部账号的标识或邮箱，使其可以放入签名令牌的值中。
+func encodeClaim(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// decodeClaim 解码 encodeClaim 编码的值。
+func decodeClaim(s string) string {
+	b, _ := base64.RawURLEncoding.DecodeString(s)
+	return string(b)
+}
+
+// hashNonce 计算 nonce 的哈希值，state 中只保存哈希值。
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewOAuthController 函数用于创建并初始化 OAuthController 实例，
+// 外部登录服务通过环境变量 OAUTH_PROVIDERS 指定的 JSON 文件配置。
+func NewOAuthController() IOAuthController {
+	db := common.GetDB()
+	db.AutoMigrate(model.ExternalIdentity{})
+	if err := service.InitOAuthProviders(os.Getenv("OAUTH_PROVIDERS")); err != nil {
+		log.Println("load oauth providers failed:", err)
+	}
+	baseURL, webURL := siteURLs()
+	return &OAuthController{DB: db, BaseURL: baseURL, WebURL: webURL}
+}
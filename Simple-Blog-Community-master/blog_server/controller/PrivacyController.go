@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strconv"
+)
+
+// PrivacyController 结构体用于处理隐私设置以及受隐私设置约束的收藏、关注、粉丝列表。
+type PrivacyController struct {
+	DB *gorm.DB
+}
+
+// IPrivacyController 接口定义了隐私控制器需要实现的一系列方法。
+type IPrivacyController interface {
+	Get(c *gin.Context)       // 查询隐私设置
+	Update(c *gin.Context)    // 修改隐私设置
+	Bookmarks(c *gin.Context) // 查询用户的收藏列表
+	Following(c *gin.Context) // 查询用户的关注列表
+	Followers(c *gin.Context) // 查询用户的粉丝列表
+}
+
+// Get 查询当前用户的隐私设置。
+func (p PrivacyController) Get(c *gin.Context) {
+	user, _ := c.Get("user")
+	response.Success(c, privacyFields(user.(model.User)), "查找成功")
+}
+
+// Update 修改当前用户的隐私设置。
+func (p PrivacyController) Update(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.UpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	updates := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"profile_visibility":   request.Profile,
+		"bookmarks_visibility": request.Bookmarks,
+		"following_visibility": request.Following,
+		"followers_visibility": request.Followers,
+	} {
+		if value == nil {
+			continue
+		}
+		if !contains(model.Visibilities, *value) {
+			response.Fail(c, nil, "可见范围错误")
+			return
+		}
+		updates[column] = *value
+	}
+	if len(updates) == 0 {
+		response.Fail(c, nil, "没有需要修改的内容")
+		return
+	}
+	var updated model.User
+	if err := p.DB.Model(&model.User{}).Where("id = ?", user.(model.User).ID).Updates(updates).Error; err != nil {
+		response.Fail(c, nil, "修改失败")
+		return
+	}
+	p.DB.Where("id = ?", user.(model.User).ID).First(&updated)
+	response.Success(c, privacyFields(updated), "修改成功")
+}
+
+// Bookmarks 分页查询用户收藏的文章，受收藏列表的可见范围约束。
+func (p PrivacyController) Bookmarks(c *gin.Context) {
+	owner, ok := p.owner(c, func(u model.User) string { return u.BookmarksVisibility })
+	if !ok {
+		return
+	}
+	pageNum, pageSize := pagination(c)
+	var articles []model.ArticleInfo
+	var count int
+	query := p.DB.Table("articles").Where("id IN (?)", ToStringArray(owner.Collects)).Where(visibleArticles(""))
+	query.Select(articleInfoFields("")).Order("created_at desc").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&articles)
+	query.Count(&count)
+	response.Success(c, gin.H{"articles": articles, "count": count}, "查找成功")
+}
+
+// Following 分页查询用户关注的人，受关注列表的可见范围约束。
+func (p PrivacyController) Following(c *gin.Context) {
+	owner, ok := p.owner(c, func(u model.User) string { return u.FollowingVisibility })
+	if !ok {
+		return
+	}
+	p.users(c, ToStringArray(owner.Following))
+}
+
+// Followers 分页查询关注了该用户的人，受粉丝列表的可见范围约束。
+func (p PrivacyController) Followers(c *gin.Context) {
+	owner, ok := p.owner(c, func(u model.User) string { return u.FollowersVisibility })
+	if !ok {
+		return
+	}
+	var ids []string
+	for _, id := range service.FollowerIds(p.DB, owner.ID) {
+		ids = append(ids, strconv.Itoa(int(id)))
+	}
+	p.users(c, ids)
+}
+
+// owner 查询路径参数 id 对应的用户，并检查访问者能否查看其指定的列表，失败时直接返回错误信息。
+func (p PrivacyController) owner(c *gin.Context, visibility func(model.User) string) (model.User, bool) {
+	var owner model.User
+	if p.DB.Where("id = ?", c.Params.ByName("id")).First(&owner).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return owner, false
+	}
+	if !service.CanView(p.DB, viewerId(c), owner, visibility(owner)) {
+		response.Response(c, http.StatusOK, 403, nil, "该用户未公开此列表")
+		return owner, false
+	}
+	return owner, true
+}
+
+// users 分页返回指定 ID 的用户简要信息。
+func (p PrivacyController) users(c *gin.Context, ids []string) {
+	pageNum, pageSize := pagination(c)
+	var users []model.UserInfo
+	var count int
+	query := p.DB.Table("users").Where("id IN (?) AND deleted_at IS NULL", ids)
+	query.Select("id, avatar, user_name").Order("id").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).Scan(&users)
+	query.Count(&count)
+	response.Success(c, gin.H{"users": users, "count": count}, "查找成功")
+}
+
+// privacyFields 返回用户的隐私设置。
+func privacyFields(user model.User) gin.H {
+	return gin.H{
+		"profile":   user.ProfileVisibility,
+		"bookmarks": user.BookmarksVisibility,
+		"following": user.FollowingVisibility,
+		"followers": user.FollowersVisibility,
+	}
+}
+
+// pagination 读取分页参数 pageNum 和 pageSize，每页最多 100 条。
+func pagination(c *gin.Context) (int, int) {
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	return pageNum, pageSize
+}
+
+// viewerId 返回当前访问者的用户 ID，未登录时返回 0。
+func viewerId(c *gin.Context) uint {
+	if user, ok := c.Get("user"); ok {
+		return user.(model.User).ID
+	}
+	return 0
+}
+
+// NewPrivacyController 函数用于创建并初始化 PrivacyController 实例。
+func NewPrivacyController() IPrivacyController {
+	return &PrivacyController{DB: common.GetDB()}
+}
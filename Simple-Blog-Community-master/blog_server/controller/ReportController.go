@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// ReportController 结构体用于处理举报及版主审核相关的请求。
+type ReportController struct {
+	DB *gorm.DB
+}
+
+// IReportController 接口定义了举报控制器需要实现的一系列方法。
+type IReportController interface {
+	Create(c *gin.Context) // 提交举报
+	List(c *gin.Context)   // 版主查询举报队列
+	Handle(c *gin.Context) // 版主处理举报
+}
+
+// Create 提交对文章或用户主页的举报，同一用户对同一对象只能有一条待处理的举报。
+func (r ReportController) Create(c *gin.Context) {
+	var request vo.CreateReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	if !contains(model.ReportReasons, request.Reason) {
+		response.Fail(c, nil, "举报原因错误")
+		return
+	}
+	if utf8.RuneCountInString(request.Detail) > 500 {
+		response.Fail(c, nil, "举报说明不能超过500字")
+		return
+	}
+	// 查找被举报内容的作者
+	var targetUserId uint
+	switch request.TargetType {
+	case model.ReportArticle:
+		var article model.Article
+		if r.DB.Where("id = ?", request.TargetId).First(&article).RecordNotFound() {
+			response.Fail(c, nil, "文章不存在")
+			return
+		}
+		targetUserId = article.UserId
+	case model.ReportUser:
+		var target model.User
+		if r.DB.Where("id = ?", request.TargetId).First(&target).RecordNotFound() {
+			response.Fail(c, nil, "用户不存在")
+			return
+		}
+		targetUserId = target.ID
+		request.TargetId = strconv.Itoa(int(target.ID))
+	default:
+		response.Fail(c, nil, "举报对象错误")
+		return
+	}
+	user, _ := c.Get("user")
+	reporterId := user.(model.User).ID
+	if targetUserId == reporterId {
+		response.Fail(c, nil, "不能举报自己")
+		return
+	}
+	var count int
+	r.DB.Model(model.Report{}).Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?",
+		reporterId, request.TargetType, request.TargetId, model.ReportPending).Count(&count)
+	if count > 0 {
+		response.Fail(c, nil, "已举报，请等待处理")
+		return
+	}
+	report := model.Report{
+		ReporterId:   reporterId,
+		TargetType:   request.TargetType,
+		TargetId:     request.TargetId,
+		TargetUserId: targetUserId,
+		Reason:       request.Reason,
+		Detail:       request.Detail,
+		Status:       model.ReportPending,
+	}
+	if err := r.DB.Create(&report).Error; err != nil {
+		response.Fail(c, nil, "举报失败")
+		return
+	}
+	response.Success(c, gin.H{"id": report.ID}, "举报成功")
+}
+
+// List 分页查询举报队列，可按状态、对象类型和原因筛选，
+// sort 为 newest（默认）、oldest 或 most（同一对象被举报次数最多的优先）。
+func (r ReportController) List(c *gin.Context) {
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	query := r.DB.Model(model.Report{}).Where("status = ?", c.DefaultQuery("status", model.ReportPending))
+	if targetType := c.Query("targetType"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	order := "created_at desc"
+	switch c.Query("sort") {
+	case "oldest":
+		order = "created_at asc"
+	case "most":
+		order = "(SELECT COUNT(*) FROM reports AS r WHERE r.target_type = reports.target_type " +
+			"AND r.target_id = reports.target_id) desc, created_at asc"
+	}
+	var reports []model.Report
+	var count int
+	query.Order(order).Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&reports)
+	query.Count(&count)
+	response.Success(c, gin.H{"reports": reports, "count": count}, "查找成功")
+}
+
+// Handle 处理举报：驳回、隐藏文章、删除文章、警告作者或封禁作者。
+// 同一对象的所有待处理举报会一并处理，并通知每位举报人处理结果。
+func (r ReportController) Handle(c *gin.Context) {
+	var request vo.HandleReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	var report model.Report
+	if r.DB.Where("id = ?", c.Params.ByName("id")).First(&report).RecordNotFound() {
+		response.Fail(c, nil, "举报不存在")
+		return
+	}
+	if report.Status != model.ReportPending {
+		response.Fail(c, nil, "举报已处理")
+		return
+	}
+	user, _ := c.Get("user")
+	moderator := user.(model.User)
+
+	status, result := model.ReportResolved, ""
+	switch request.Action {
+	case model.ActionDismiss:
+		status, result = model.ReportDismissed, "经审核未发现违规"
+	case model.ActionHide, model.ActionDelete:
+		if report.TargetType != model.ReportArticle {
+			response.Fail(c, nil, "只能隐藏或删除文章")
+			return
+		}
+		var article model.Article
+		if r.DB.Where("id = ?", report.TargetId).First(&article).RecordNotFound() {
+			response.Fail(c, nil, "文章不存在")
+			return
+		}
+		var err error
+		if request.Action == model.ActionHide {
+			err = r.DB.Model(&article).UpdateColumn("hidden", true).Error
+			result = "相关文章已被隐藏"
+		} else {
+			err = deleteArticle(r.DB, article)
+			result = "相关文章已被删除"
+		}
+		if err != nil {
+			response.Fail(c, nil, "处理失败")
+			return
+		}
+	case model.ActionWarn:
+		service.Notify(r.DB, report.TargetUserId, moderator.ID, model.NotifyWarn, report.TargetId,
+			"你发布的内容违反了社区规范，请注意："+request.Note)
+		result = "已对作者进行警告"
+	case model.ActionSuspend:
+		if request.Days <= 0 {
+			response.Fail(c, nil, "封禁天数错误")
+			return
+		}
+		var target model.User
+		if r.DB.Where("id = ?", report.TargetUserId).First(&target).RecordNotFound() {
+			response.Fail(c, nil, "用户不存在")
+			return
+		}
+		if canModerate(target) {
+			response.Fail(c, nil, "不能封禁版主或管理员")
+			return
+		}
+		until := time.Now().AddDate(0, 0, request.Days)
+		if err := service.SuspendUser(r.DB, target, until, request.Note, false); err != nil {
+			response.Fail(c, nil, "处理失败")
+			return
+		}
+		result = "已对作者进行封禁"
+	default:
+		response.Fail(c, nil, "处理方式错误")
+		return
+	}
+
+	// 一并处理同一对象的所有待处理举报
+	var reports []model.Report
+	r.DB.Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetId, model.ReportPending).
+		Find(&reports)
+	now := time.Now()
+	if err := r.DB.Model(model.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetId, model.ReportPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"action":       request.Action,
+			"note":         request.Note,
+			"moderator_id": moderator.ID,
+			"handled_at":   now,
+		}).Error; err != nil {
+		response.Fail(c, nil, "处理失败")
+		return
+	}
+	service.Audit(r.DB, c, moderator.ID, model.AuditReportHandle, "report", strconv.Itoa(int(report.ID)), report, gin.H{
+		"status":  status,
+		"action":  request.Action,
+		"note":    request.Note,
+		"days":    request.Days,
+		"handled": len(reports),
+	})
+	for _, handled := range reports {
+		service.Notify(r.DB, handled.ReporterId, moderator.ID, model.NotifyReport, strconv.Itoa(int(handled.ID)),
+			"你的举报已处理："+result)
+	}
+	response.Success(c, gin.H{"handled": len(reports)}, "处理成功")
+}
+
+// canModerate 判断用户是否为版主或管理员。
+func canModerate(user model.User) bool {
+	return user.Role == model.RoleModerator || user.Role == model.RoleAdmin
+}
+
+// contains 判断字符串切片中是否包含指定的字符串。
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReportController 函数用于创建并初始化 ReportController 实例。
+func NewReportController() IReportController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Report{})
+	return &ReportController{DB: db}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"time"
+)
+
+// SessionController 结构体用于处理登录会话（设备）管理相关的请求。
+type SessionController struct {
+	DB *gorm.DB
+}
+
+// ISessionController 接口定义了会话控制器需要实现的一系列方法。
+type ISessionController interface {
+	List(c *gin.Context)         // 查询有效的会话
+	Revoke(c *gin.Context)       // 注销指定会话
+	RevokeOthers(c *gin.Context) // 注销当前会话以外的所有会话
+	Logout(c *gin.Context)       // 退出登录，注销当前会话
+}
+
+// List 查询当前用户所有有效的会话，按最近使用时间倒序，current 表示是否为当前会话。
+func (s SessionController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	current, _ := c.Get("session")
+	var sessions []model.Session
+	s.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.(model.User).ID, time.Now()).
+		Order("last_seen_at desc").Find(&sessions)
+	list := make([]gin.H, len(sessions))
+	for i, session := range sessions {
+		list[i] = gin.H{
+			"id":           session.ID,
+			"device":       session.Device,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"current":      session.ID == current.(model.Session).ID,
+		}
+	}
+	response.Success(c, gin.H{"sessions": list}, "查找成功")
+}
+
+// Revoke 注销当前用户的指定会话，该会话的 token 立即失效。
+func (s SessionController) Revoke(c *gin.Context) {
+	user, _ := c.Get("user")
+	found, err := service.RevokeSession(s.DB, user.(model.User).ID, c.Params.ByName("id"))
+	if err != nil {
+		response.Fail(c, nil, "注销失败")
+		return
+	}
+	if !found {
+		response.Fail(c, nil, "会话不存在")
+		return
+	}
+	service.Audit(s.DB, c, user.(model.User).ID, model.AuditSessionRevoke, "session", c.Params.ByName("id"), nil, nil)
+	response.Success(c, nil, "注销成功")
+}
+
+// RevokeOthers 注销当前会话以外的所有会话，例如怀疑账号在其他设备上被盗用时。
+func (s SessionController) RevokeOthers(c *gin.Context) {
+	user, _ := c.Get("user")
+	current, _ := c.Get("session")
+	if err := service.RevokeSessions(s.DB, user.(model.User).ID, current.(model.Session).ID); err != nil {
+		response.Fail(c, nil, "注销失败")
+		return
+	}
+	service.Audit(s.DB, c, user.(model.User).ID, model.AuditSessionRevoke, "user", strconv.Itoa(int(user.(model.User).ID)), nil, gin.H{"except": current.(model.Session).ID})
+	response.Success(c, nil, "已注销其他设备")
+}
+
+// Logout 退出登录，注销当前会话。
+func (s SessionController) Logout(c *gin.Context) {
+	user, _ := c.Get("user")
+	current, _ := c.Get("session")
+	if _, err := service.RevokeSession(s.DB, user.(model.User).ID, current.(model.Session).ID); err != nil {
+		response.Fail(c, nil, "退出失败")
+		return
+	}
+	service.Audit(s.DB, c, user.(model.User).ID, model.AuditLogout, "session", current.(model.Session).ID, nil, nil)
+	response.Success(c, nil, "已退出登录")
+}
+
+// NewSessionController 函数用于创建并初始化 SessionController 实例。
+func NewSessionController() ISessionController {
+	db := common.GetDB()
+	db.AutoMigrate(model.Session{})
+	return &SessionController{DB: db}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxAccessTokens 是每个用户最多拥有的有效个人访问令牌数量。
+const maxAccessTokens = 20
+
+// TokenController 结构体用于处理个人访问令牌相关的请求。
+type TokenController struct {
+	DB *gorm.DB
+}
+
+// ITokenController 接口定义了个人访问令牌控制器需要实现的一系列方法。
+type ITokenController interface {
+	List(c *gin.Context)   // 查询令牌
+	Create(c *gin.Context) // 创建令牌
+	Revoke(c *gin.Context) // 撤销令牌
+}
+
+// List 查询当前用户未撤销的个人访问令牌，包括已过期的令牌。
+func (t TokenController) List(c *gin.Context) {
+	user, _ := c.Get("user")
+	var tokens []model.AccessToken
+	t.DB.Where("user_id = ? AND revoked_at IS NULL", user.(model.User).ID).Order("id desc").Find(&tokens)
+	response.Success(c, gin.H{"tokens": tokens, "scopes": model.AccessTokenScopes}, "查找成功")
+}
+
+// Create 创建个人访问令牌，令牌明文只在创建时返回一次。
+func (t TokenController) Create(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.CreateTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || utf8.RuneCountInString(request.Name) > 50 {
+		response.Fail(c, nil, "名称长度需要在 1 到 50 个字符之间")
+		return
+	}
+	if len(request.Scopes) == 0 {
+		response.Fail(c, nil, "请选择权限范围")
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !contains(model.AccessTokenScopes, scope) {
+			response.Fail(c, nil, "权限范围错误："+scope)
+			return
+		}
+	}
+	if request.ExpiresInDays < 0 || request.ExpiresInDays > 3650 {
+		response.Fail(c, nil, "有效天数错误")
+		return
+	}
+	var count int
+	t.DB.Model(&model.AccessToken{}).Where("user_id = ? AND revoked_at IS NULL", user.(model.User).ID).Count(&count)
+	if count >= maxAccessTokens {
+		response.Fail(c, nil, "令牌数量已达上限，请先撤销不再使用的令牌")
+		return
+	}
+	var expiresAt *model.Time
+	if request.ExpiresInDays > 0 {
+		expires := model.Time(time.Now().AddDate(0, 0, request.ExpiresInDays))
+		expiresAt = &expires
+	}
+	plain, token, err := service.CreateAccessToken(t.DB, user.(model.User).ID, request.Name, request.Scopes, expiresAt)
+	if err != nil {
+		response.Fail(c, nil, "创建失败")
+		return
+	}
+	service.Audit(t.DB, c, user.(model.User).ID, model.AuditTokenCreate, "token", strconv.Itoa(int(token.ID)), nil, gin.H{"name": token.Name, "scopes": token.Scopes})
+	response.Success(c, gin.H{"token": plain, "info": token}, "创建成功，令牌只显示这一次，请妥善保存")
+}
+
+// Revoke 撤销个人访问令牌，使用该令牌的请求立即失效。
+func (t TokenController) Revoke(c *gin.Context) {
+	user, _ := c.Get("user")
+	result := t.DB.Model(&model.AccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Params.ByName("id"), user.(model.User).ID).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		response.Fail(c, nil, "撤销失败")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.Fail(c, nil, "令牌不存在")
+		return
+	}
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	service.GetHub().CloseToken(uint(id))
+	service.Audit(t.DB, c, user.(model.User).ID, model.AuditTokenRevoke, "token", c.Params.ByName("id"), nil, nil)
+	response.Success(c, nil, "撤销成功")
+}
+
+// NewTokenController 函数用于创建并初始化 TokenController 实例。
+func NewTokenController() ITokenController {
+	db := common.GetDB()
+	db.AutoMigrate(model.AccessToken{})
+	return &TokenController{DB: db}
+}
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// twoFactorChallengeTTL 是登录时挑战令牌的有效期。
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// TwoFactorController 结构体用于处理两步验证相关的请求。
+type TwoFactorController struct {
+	DB *gorm.DB
+}
+
+// ITwoFactorController 接口定义了两步验证控制器需要实现的一系列方法。
+type ITwoFactorController interface {
+	Status(c *gin.Context)        // 查询两步验证状态
+	Enroll(c *gin.Context)        // 生成密钥
+	Confirm(c *gin.Context)       // 确认并开启两步验证
+	Disable(c *gin.Context)       // 关闭两步验证
+	RecoveryCodes(c *gin.Context) // 重新生成恢复码
+	Login(c *gin.Context)         // 使用挑战令牌和验证码完成登录
+}
+
+// Status 查询当前用户是否开启了两步验证以及剩余的恢复码数量。
+func (t TwoFactorController) Status(c *gin.Context) {
+	user, _ := c.Get("user")
+	var remaining int
+	t.DB.Model(&model.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.(model.User).ID).Count(&remaining)
+	response.Success(c, gin.H{"enabled": user.(model.User).TOTPEnabled, "recovery_codes": remaining}, "查找成功")
+}
+
+// Enroll 生成新的密钥，返回密钥和 otpauth:// 地址供验证器应用扫码，确认之前不会生效。
+func (t TwoFactorController) Enroll(c *gin.Context) {
+	user, _ := c.Get("user")
+	if user.(model.User).TOTPEnabled {
+		response.Fail(c, nil, "已开启两步验证")
+		return
+	}
+	secret, err := service.GenerateTOTPSecret()
+	if err != nil {
+		response.Fail(c, nil, "系统异常")
+		return
+	}
+	if err := t.DB.Model(&model.User{}).Where("id = ?", user.(model.User).ID).UpdateColumn("totp_secret", secret).Error; err != nil {
+		response.Fail(c, nil, "系统异常")
+		return
+	}
+	account := user.(model.User).PhoneNumber
+	if user.(model.User).Email != nil && user.(model.User).EmailVerified {
+		account = *user.(model.User).Email
+	}
+	response.Success(c, gin.H{"secret": secret, "uri": service.TOTPURI(secret, account)}, "请使用验证器应用扫码后输入验证码确认")
+}
+
+// Confirm 使用验证器应用中的验证码确认密钥，开启两步验证并返回恢复码。恢复码只返回这一次。
+func (t TwoFactorController) Confirm(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if user.(model.User).TOTPEnabled {
+		response.Fail(c, nil, "已开启两步验证")
+		return
+	}
+	if user.(model.User).TOTPSecret == "" {
+		response.Fail(c, nil, "请先生成密钥")
+		return
+	}
+	step, ok := service.ValidateTOTP(user.(model.User).TOTPSecret, request.Code, 0)
+	if !ok {
+		response.Fail(c, nil, "验证码错误")
+		return
+	}
+	tx := t.DB.Begin()
+	if err := tx.Model(&model.User{}).Where("id = ?", user.(model.User).ID).
+		Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "开启失败")
+		return
+	}
+	codes, err := resetRecoveryCodes(tx, user.(model.User).ID)
+	if err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "开启失败")
+		return
+	}
+	tx.Commit()
+	service.Audit(t.DB, c, user.(model.User).ID, model.AuditTwoFactorOn, "user", strconv.Itoa(int(user.(model.User).ID)), nil, nil)
+	response.Success(c, gin.H{"recovery_codes": codes}, "已开启两步验证，请妥善保存恢复码")
+}
+
+// Disable 关闭两步验证，需要提供密码以及动态验证码或恢复码。
+func (t TwoFactorController) Disable(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if !user.(model.User).TOTPEnabled {
+		response.Fail(c, nil, "未开启两步验证")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.(model.User).Password), []byte(request.Password)); err != nil {
+		response.Fail(c, nil, "密码错误")
+		return
+	}
+	if _, ok := verifySecondFactor(t.DB, user.(model.User), request.Code); !ok {
+		response.Fail(c, nil, "验证码错误")
+		return
+	}
+	tx := t.DB.Begin()
+	if err := tx.Model(&model.User{}).Where("id = ?", user.(model.User).ID).
+		Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "关闭失败")
+		return
+	}
+	if err := tx.Where("user_id = ?", user.(model.User).ID).Delete(model.RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "关闭失败")
+		return
+	}
+	tx.Commit()
+	service.Audit(t.DB, c, user.(model.User).ID, model.AuditTwoFactorOff, "user", strconv.Itoa(int(user.(model.User).ID)), nil, nil)
+	response.Success(c, nil, "已关闭两步验证")
+}
+
+// RecoveryCodes 使用动态验证码确认后重新生成恢复码，之前的恢复码全部失效。
+func (t TwoFactorController) RecoveryCodes(c *gin.Context) {
+	user, _ := c.Get("user")
+	var request vo.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if !user.(model.User).TOTPEnabled {
+		response.Fail(c, nil, "未开启两步验证")
+		return
+	}
+	step, ok := service.ValidateTOTP(user.(model.User).TOTPSecret, request.Code, user.(model.User).TOTPLastStep)
+	if !ok || !useTOTPStep(t.DB, user.(model.User).ID, step) {
+		response.Fail(c, nil, "验证码错误")
+		return
+	}
+	tx := t.DB.Begin()
+	codes, err := resetRecoveryCodes(tx, user.(model.User).ID)
+	if err != nil {
+		tx.Rollback()
+		response.Fail(c, nil, "生成失败")
+		return
+	}
+	tx.Commit()
+	service.Audit(t.DB, c, user.(model.User).ID, model.AuditRecoveryReset, "user", strconv.Itoa(int(user.(model.User).ID)), nil, nil)
+	response.Success(c, gin.H{"recovery_codes": codes}, "已重新生成恢复码，请妥善保存")
+}
+
+// Login 使用登录时返回的挑战令牌和动态验证码（或恢复码）完成登录，发放 token。
+func (t TwoFactorController) Login(c *gin.Context) {
+	var request vo.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	userId, version, err := service.ParseSignedToken(service.LinkTwoFactor, request.Challenge)
+	if err != nil {
+		response.Fail(c, nil, "登录已过期，请重新登录")
+		return
+	}
+	var user model.User
+	if t.DB.Where("id = ?", userId).First(&user).RecordNotFound() ||
+		strconv.Itoa(user.TokenVersion) != version || !user.TOTPEnabled {
+		response.Fail(c, nil, "登录已过期，请重新登录")
+		return
+	}
+	// 验证码错误同样计入登录失败，达到次数后锁定
+	if wait := service.LoginLocked(service.LoginKey(user.ID, ""), c.ClientIP()); wait > 0 {
+		response.TooManyRequests(c, wait)
+		return
+	}
+	id := strconv.Itoa(int(user.ID))
+	recovery, ok := verifySecondFactor(t.DB, user, request.Code)
+	if !ok {
+		service.Audit(t.DB, c, user.ID, model.AuditLoginFailed, "user", id, nil, gin.H{"reason": "两步验证码错误"})
+		if wait := service.LoginFailed(service.LoginKey(user.ID, ""), c.ClientIP()); wait > 0 {
+			response.TooManyRequests(c, wait)
+			return
+		}
+		response.Fail(c, nil, "验证码错误")
+		return
+	}
+	if user.IsSuspended() {
+		response.Response(c, http.StatusOK, 403, nil, service.SuspensionMessage(user))
+		return
+	}
+	token, err := service.StartSession(t.DB, c, user)
+	if err != nil {
+		response.Fail(c, nil, "系统异常")
+		return
+	}
+	service.LoginSucceeded(service.LoginKey(user.ID, ""))
+	if recovery {
+		service.Audit(t.DB, c, user.ID, model.AuditRecoveryUsed, "user", id, nil, nil)
+	}
+	service.Audit(t.DB, c, user.ID, model.AuditLogin, "user", id, nil, nil)
+	response.Success(c, gin.H{"token": token}, "登录成功")
+}
+
+// verifySecondFactor 校验动态验证码或恢复码，返回是否使用了恢复码。
+// 动态验证码和恢复码都只能使用一次。
+func verifySecondFactor(db *gorm.DB, user model.User, code string) (bool, bool) {
+	if step, ok := service.ValidateTOTP(user.TOTPSecret, code, user.TOTPLastStep); ok {
+		return false, useTOTPStep(db, user.ID, step)
+	}
+	result := db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, service.HashRecoveryCode(code)).
+		UpdateColumn("used_at", time.Now())
+	return true, result.Error == nil && result.RowsAffected == 1
+}
+
+// useTOTPStep 记录验证通过的时间步，并发提交同一个验证码时只有一个请求能够成功。
+func useTOTPStep(db *gorm.DB, userId uint, step int64) bool {
+	result := db.Model(&model.User{}).Where("id = ? AND totp_last_step < ?", userId, step).
+		UpdateColumn("totp_last_step", step)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// resetRecoveryCodes 删除用户的旧恢复码并生成新的一组，返回明文。
+func resetRecoveryCodes(tx *gorm.DB, userId uint) ([]string, error) {
+	codes, hashes, err := service.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userId).Delete(model.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		if err := tx.Create(&model.RecoveryCode{UserId: userId, CodeHash: hash}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// NewTwoFactorController 函数用于创建并初始化 TwoFactorController 实例。
+func NewTwoFactorController() ITwoFactorController {
+	db := common.GetDB()
+	db.AutoMigrate(model.RecoveryCode{})
+	// 旧版本在 code_hash 上建立了全局唯一索引，不同用户生成相同的恢复码时会插入失败
+	if db.Dialect().HasIndex("recovery_codes", "code_hash") {
+		db.Model(&model.RecoveryCode{}).RemoveIndex("code_hash")
+	}
+	return &TwoFactorController{DB: db}
+}
@@ -4,10 +4,15 @@ import (
 	"blog_server/common"
 	"blog_server/model"
 	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Register 注册
@@ -27,6 +32,22 @@ func Register(c *gin.Context) {
 	userName := requestUser.UserName
 	phoneNumber := requestUser.PhoneNumber
 	password := requestUser.Password
+	// 用户名不能包含敏感词
+	if service.GetFilter().Check(userName).Action != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 422,
+			"msg":  "用户名包含敏感词",
+		})
+		return
+	}
+	// 密码需要满足强度要求
+	if err := service.CheckPassword(password, phoneNumber, userName); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 422,
+			"msg":  err.Error(),
+		})
+		return
+	}
 	// 验证手机号是否已经被注册
 	var user model.User
 	// 在数据库中查询是否存在该手机号的用户
@@ -41,6 +62,14 @@ func Register(c *gin.Context) {
 		// 终止函数执行
 		return
 	}
+	// 手机号需要先通过验证码验证
+	if err := service.ConsumeVerification(db, phoneNumber, model.VerifyRegister); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 422,
+			"msg":  "请先验证手机号",
+		})
+		return
+	}
 
 	// 使用bcrypt库对用户密码进行加密
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -49,6 +78,8 @@ func Register(c *gin.Context) {
 	newUser := model.User{
 		UserName:    userName,
 		PhoneNumber: phoneNumber,
+		// 注册前已经验证过手机号
+		PhoneVerified: true,
 		// 将加密后的密码赋值给newUser的Password字段
 		Password: string(hashedPassword),
 		// 设置默认头像
@@ -62,6 +93,7 @@ func Register(c *gin.Context) {
 
 	// 将新用户的数据保存到数据库中
 	db.Create(&newUser)
+	service.Audit(db, c, newUser.ID, model.AuditRegister, "user", strconv.Itoa(int(newUser.ID)), nil, service.UserSnapshot(newUser))
 
 	// 返回状态码200（OK），表示注册成功
 	c.JSON(http.StatusOK, gin.H{
@@ -76,12 +108,35 @@ func Login(c *gin.Context) {
 	// 获取参数
 	var requestUser model.User
 	c.Bind(&requestUser)
-	phoneNumber := requestUser.PhoneNumber
+	// 可以使用手机号或已验证的邮箱登录，手机号字段中包含 @ 时同样视为邮箱
+	account := requestUser.PhoneNumber
+	if requestUser.Email != nil && *requestUser.Email != "" {
+		account = *requestUser.Email
+	}
+	if strings.Contains(account, "@") {
+		account = strings.ToLower(strings.TrimSpace(account))
+	}
 	password := requestUser.Password
-	// 数据验证
+	// 查询账号
 	var user model.User
-	db.Where("phone_number =?", phoneNumber).First(&user)
+	if strings.Contains(account, "@") {
+		db.Where("email = ? AND email_verified = ?", account, true).First(&user)
+	} else {
+		db.Where("phone_number =?", account).First(&user)
+	}
+	// 连续登录失败的账号或 IP 在锁定期间不能登录
+	lockKey := service.LoginKey(user.ID, account)
+	if wait := service.LoginLocked(lockKey, c.ClientIP()); wait > 0 {
+		response.TooManyRequests(c, wait)
+		return
+	}
+	// 数据验证
 	if user.ID == 0 {
+		service.Audit(db, c, 0, model.AuditLoginFailed, "user", "", nil, gin.H{"account": account, "reason": "用户不存在"})
+		if wait := service.LoginFailed(lockKey, c.ClientIP()); wait > 0 {
+			response.TooManyRequests(c, wait)
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"code": 422,
 			"msg":  "用户不存在",
@@ -89,15 +144,42 @@ func Login(c *gin.Context) {
 		return
 	}
 	// 判断密码是否正确
+	userId := strconv.Itoa(int(user.ID))
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		service.Audit(db, c, user.ID, model.AuditLoginFailed, "user", userId, nil, gin.H{"reason": "密码错误"})
+		if wait := service.LoginFailed(lockKey, c.ClientIP()); wait > 0 {
+			response.TooManyRequests(c, wait)
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"code": 422,
 			"msg":  "密码错误",
 		})
 		return
 	}
+	// 被封禁的用户不能登录
+	if user.IsSuspended() {
+		service.AuditThrottled(db, c, service.SuspendedAuditWindow, user.ID, model.AuditSuspendedLogin, "user", userId, nil, nil)
+		c.JSON(http.StatusOK, gin.H{
+			"code": 403,
+			"msg":  service.SuspensionMessage(user),
+		})
+		return
+	}
+	// 开启了两步验证时先返回挑战令牌，通过 /login/2fa 提交动态验证码后才发放 token
+	if user.TOTPEnabled {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 200,
+			"data": gin.H{"two_factor": true, "challenge": service.NewSignedToken(service.LinkTwoFactor, user.ID, strconv.Itoa(user.TokenVersion), twoFactorChallengeTTL)},
+			"msg":  "请输入两步验证码",
+		})
+		return
+	}
+	// 完成全部验证后才清除登录失败次数。开启两步验证时在 /login/2fa 验证通过后清除，
+	// 否则知道密码的人可以在猜测两步验证码的间隙重新登录来清除锁定
+	service.LoginSucceeded(lockKey)
 	// 发放token
-	token, err := common.ReleaseToken(user)
+	token, err := service.StartSession(db, c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
@@ -105,6 +187,7 @@ func Login(c *gin.Context) {
 		})
 		return
 	}
+	service.Audit(db, c, user.ID, model.AuditLogin, "user", userId, nil, nil)
 	// 返回结果
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
@@ -113,12 +196,84 @@ func Login(c *gin.Context) {
 	})
 }
 
+// ChangePassword 修改密码，需要提供当前密码。修改后之前发放的 token 全部失效，并为当前设备创建新的会话
+func ChangePassword(c *gin.Context) {
+	db := common.GetDB()
+	user, _ := c.Get("user")
+	var request vo.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.(model.User).Password), []byte(request.OldPassword)); err != nil {
+		response.Fail(c, nil, "当前密码错误")
+		return
+	}
+	if err := service.CheckPassword(request.NewPassword, user.(model.User).PhoneNumber, user.(model.User).UserName); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	updated, err := service.SetPassword(db, user.(model.User), request.NewPassword)
+	if err != nil {
+		response.Fail(c, nil, "修改失败")
+		return
+	}
+	token, err := service.StartSession(db, c, updated)
+	if err != nil {
+		response.Fail(c, nil, "系统异常")
+		return
+	}
+	service.Audit(db, c, updated.ID, model.AuditPasswordChange, "user", strconv.Itoa(int(updated.ID)), nil, nil)
+	response.Success(c, gin.H{"token": token}, "修改成功")
+}
+
+// ResetPassword 使用发送到手机号的验证码重置密码，重置后之前发放的 token 全部失效，需要重新登录
+func ResetPassword(c *gin.Context) {
+	db := common.GetDB()
+	var request vo.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	var user model.User
+	if db.Where("phone_number = ?", request.PhoneNumber).First(&user).RecordNotFound() {
+		response.Fail(c, nil, service.ErrCodeInvalid.Error())
+		return
+	}
+	if err := service.VerifyCode(db, request.PhoneNumber, model.VerifyReset, request.Code); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	if err := service.CheckPassword(request.NewPassword, user.PhoneNumber, user.UserName); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	if err := service.ConsumeVerification(db, request.PhoneNumber, model.VerifyReset); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	if _, err := service.SetPassword(db, user, request.NewPassword); err != nil {
+		response.Fail(c, nil, "重置失败")
+		return
+	}
+	// 重置密码后解除因登录失败产生的锁定
+	service.LoginSucceeded(service.LoginKey(user.ID, ""))
+	service.Audit(db, c, user.ID, model.AuditPasswordReset, "user", strconv.Itoa(int(user.ID)), nil, nil)
+	response.Success(c, nil, "重置成功，请重新登录")
+}
+
 // GetInfo 登录后获取信息
 func GetInfo(c *gin.Context) {
 	// 获取上下文中的用户信息
 	user, _ := c.Get("user")
 	// 返回用户信息
-	response.Success(c, gin.H{"id": user.(model.User).ID, "avatar": user.(model.User).Avatar}, "登录获取信息成功")
+	response.Success(c, gin.H{
+		"id":             user.(model.User).ID,
+		"avatar":         user.(model.User).Avatar,
+		"email":          user.(model.User).Email,
+		"email_verified": user.(model.User).EmailVerified,
+		"deletion":       deletionStatus(user.(model.User)),
+	}, "登录获取信息成功")
 	//c.JSON(http.StatusOK, gin.H{
 	//	"code": 200,
 	//	"data": gin.H{"id": user.(model.User).ID, "avatar": user.(model.User).Avatar},
@@ -129,113 +284,275 @@ func GetInfo(c *gin.Context) {
 // GetBriefInfo 获取简要信息
 func GetBriefInfo(c *gin.Context) {
 	db := common.GetDB()
-	// 获取path中的userId
-	userId := c.Params.ByName("id")
-	// 判断用户身份
 	user, _ := c.Get("user")
-	//var self bool
-	var curUser model.User
-	if userId == strconv.Itoa(int(user.(model.User).ID)) {
-		//self = true
-		curUser = user.(model.User)
-	} else {
-		//self = false
-		db.Where("id =?", userId).First(&curUser)
-		if curUser.ID == 0 {
-			response.Fail(c, nil, "用户不存在")
-			return
-		}
+	curUser, ok := findUser(c, db)
+	if !ok {
+		return
 	}
 	// 返回用户简要信息
-	response.Success(c, gin.H{"id": curUser.ID, "name": curUser.UserName, "avatar": curUser.Avatar, "loginId": user.(model.User).ID}, "查找成功")
+	info := briefInfo(db, curUser, user.(model.User).ID)
+	info["loginId"] = user.(model.User).ID
+	response.Success(c, info, "查找成功")
 }
 
 // GetDetailedInfo 函数用于获取用户的详细信息。
 func GetDetailedInfo(c *gin.Context) {
-	db := common.GetDB()            // 获取数据库连接
-	userId := c.Params.ByName("id") // 从请求的路径中获取用户 ID 参数
-	user, _ := c.Get("user")        // 从 Gin 上下文中获取当前登录的用户信息
-	var curUser model.User          // 声明一个 User 类型的变量用于存储当前用户信息
-	// 判断请求的用户 ID 是否与当前登录用户的 ID 相同
-	if userId == strconv.Itoa(int(user.(model.User).ID)) {
-		curUser = user.(model.User) // 如果相同，当前用户即为当前登录用户
-	} else {
-		// 如果不同，根据 userId 查找用户
-		db.Where("id = ?", userId).First(&curUser)
-		// 如果查找不到用户，返回错误信息
-		if curUser.ID == 0 {
-			response.Fail(c, nil, "用户不存在")
-			return
-		}
+	db := common.GetDB()     // 获取数据库连接
+	user, _ := c.Get("user") // 从 Gin 上下文中获取当前登录的用户信息
+	curUser, ok := findUser(c, db)
+	if !ok {
+		return
 	}
+	// 构建并返回用户详细信息的响应
+	info := detailedInfo(db, curUser, user.(model.User).ID)
+	info["loginId"] = user.(model.User).ID
+	response.Success(c, info, "查找成功")
+}
+
+// PublicBriefInfo 公开的用户简要信息，未登录的访问者也可以查看，不包含 loginId 等与访问者相关的字段
+func PublicBriefInfo(c *gin.Context) {
+	db := common.GetDB()
+	curUser, ok := findUser(c, db)
+	if !ok {
+		return
+	}
+	response.Success(c, briefInfo(db, curUser, viewerId(c)), "查找成功")
+}
+
+// PublicDetailedInfo 公开的用户详细信息，未登录的访问者也可以查看，不包含 loginId 等与访问者相关的字段
+func PublicDetailedInfo(c *gin.Context) {
+	db := common.GetDB()
+	curUser, ok := findUser(c, db)
+	if !ok {
+		return
+	}
+	response.Success(c, detailedInfo(db, curUser, viewerId(c)), "查找成功")
+}
+
+// findUser 根据路径中的 id 查找用户，查找不到时直接返回错误信息
+func findUser(c *gin.Context, db *gorm.DB) (model.User, bool) {
+	var curUser model.User
+	// 查询的是当前登录用户本人时直接使用上下文中的用户信息
+	if user, ok := c.Get("user"); ok && c.Params.ByName("id") == strconv.Itoa(int(user.(model.User).ID)) {
+		return user.(model.User), true
+	}
+	db.Where("id = ?", c.Params.ByName("id")).First(&curUser)
+	if curUser.ID == 0 {
+		response.Fail(c, nil, "用户不存在")
+		return curUser, false
+	}
+	return curUser, true
+}
+
+// briefInfo 返回访问者可以看到的用户简要信息，viewerId 为 0 表示未登录的访问者
+func briefInfo(db *gorm.DB, curUser model.User, viewerId uint) gin.H {
+	info := gin.H{
+		"id":     curUser.ID,
+		"name":   curUser.UserName,
+		"avatar": curUser.Avatar,
+		"handle": curUser.Handle,
+	}
+	if service.CanView(db, viewerId, curUser, curUser.ProfileVisibility) {
+		info["bio"] = curUser.Bio
+	}
+	return info
+}
+
+// detailedInfo 返回访问者可以看到的用户详细信息，包括文章、收藏和关注列表，viewerId 为 0 表示未登录的访问者
+func detailedInfo(db *gorm.DB, curUser model.User, viewerId uint) gin.H {
 	// 声明用于存储文章、收藏文章、关注用户信息的变量
 	var articles, collects []model.ArticleInfo
 	var following []model.UserInfo
-	// 声明用于存储收藏文章 ID 和关注用户 ID 的字符串数组
-	var collist, follist []string
-	// 将当前用户收藏和关注的数据转换为字符串数组
-	collist = ToStringArray(curUser.Collects)
-	follist = ToStringArray(curUser.Following)
-	// 查询当前用户的文章信息
-	db.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-		Where("user_id = ?", userId).Order("created_at desc").Find(&articles)
-	// 查询当前用户收藏的文章信息
-	db.Table("articles").Select("id, category_id, title, LEFT(content,80) AS content, head_image, created_at").
-		Where("id IN (?)", collist).Order("created_at desc").Find(&collects)
-	// 查询当前用户关注的人的信息
-	db.Table("users").Select("id, avatar, user_name").
-		Where("id IN (?)", follist).Find(&following)
-	// 构建并返回用户详细信息的响应
-	response.Success(c, gin.H{
-		"id":        curUser.ID,
-		"name":      curUser.UserName,
-		"avatar":    curUser.Avatar,
-		"loginId":   user.(model.User).ID,
-		"articles":  articles,
-		"collects":  collects,
-		"following": following,
-		"fans":      curUser.Fans,
-	}, "查找成功")
+	// 查询当前用户的文章信息，不可见的文章只有作者本人可以看到
+	articleQuery := db.Table("articles").Where("user_id = ?", curUser.ID)
+	if curUser.ID != viewerId {
+		articleQuery = articleQuery.Where(visibleArticles(""))
+	}
+	articleQuery.Select(articleInfoFields("")).Order("created_at desc").Find(&articles)
+	// 查询当前用户收藏的文章信息，受收藏列表的可见范围约束
+	collectsVisible := service.CanView(db, viewerId, curUser, curUser.BookmarksVisibility)
+	if collectsVisible {
+		db.Table("articles").Select(articleInfoFields("")).
+			Where("id IN (?)", ToStringArray(curUser.Collects)).Where(visibleArticles("")).Order("created_at desc").Find(&collects)
+	}
+	// 查询当前用户关注的人的信息，受关注列表的可见范围约束
+	followingVisible := service.CanView(db, viewerId, curUser, curUser.FollowingVisibility)
+	if followingVisible {
+		db.Table("users").Select("id, avatar, user_name").
+			Where("id IN (?)", ToStringArray(curUser.Following)).Find(&following)
+	}
+	// 统计当前用户所有文章的总浏览数
+	var views struct{ Total int }
+	db.Table("articles").Select("COALESCE(SUM(view_count), 0) AS total").Where("user_id = ?", curUser.ID).Scan(&views)
+	info := profileFields(db, curUser, viewerId)
+	info["articles"] = articles
+	info["collects"] = collects
+	info["collects_hidden"] = !collectsVisible
+	info["following"] = following
+	info["following_hidden"] = !followingVisible
+	info["views"] = views.Total
+	return info
 }
 
-// ModifyAvatar 修改头像
-func ModifyAvatar(c *gin.Context) {
+// UpdateProfile 修改个人资料，请求中提供的字段在一次更新中全部生效，任一字段校验失败时都不修改
+func UpdateProfile(c *gin.Context) {
 	db := common.GetDB()
-	// 获取用户ID
 	user, _ := c.Get("user")
-	// 获取参数
-	var requestUser model.User
-	c.Bind(&requestUser)
-	avatar := requestUser.Avatar
-	// 查找用户
-	var curUser model.User
-	db.Where("id = ?", user.(model.User).ID).First(&curUser)
-	// 更新信息
-	if err := db.Model(&curUser).Update("avatar", avatar).Error; err != nil {
+	var request vo.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	updates := map[string]interface{}{}
+	// 文本字段：检查长度和敏感词
+	texts := []struct {
+		value  *string
+		column string
+		label  string
+		min    int
+		max    int
+	}{
+		{request.UserName, "user_name", "用户名", 1, 20},
+		{request.Bio, "bio", "个人简介", 0, 500},
+		{request.Location, "location", "所在地", 0, 50},
+	}
+	for _, text := range texts {
+		if text.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*text.value)
+		if length := utf8.RuneCountInString(value); length < text.min || length > text.max {
+			response.Fail(c, nil, text.label+"长度需要在 "+strconv.Itoa(text.min)+" 到 "+strconv.Itoa(text.max)+" 个字符之间")
+			return
+		}
+		if service.GetFilter().Check(value).Action != "" {
+			response.Fail(c, nil, text.label+"包含敏感词")
+			return
+		}
+		updates[text.column] = value
+	}
+	// 图片和链接字段：头像和封面图可以是上传后得到的站内地址或外部链接
+	images := []struct {
+		value  *string
+		column string
+	}{
+		{request.Avatar, "avatar"},
+		{request.CoverImage, "cover_image"},
+		{request.Website, "website"},
+	}
+	for _, image := range images {
+		if image.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*image.value)
+		if image.column == "avatar" && value == "" {
+			value = "/images/default_avatar.png"
+		}
+		if image.column == "website" || !strings.HasPrefix(value, "/images/") {
+			if err := service.CheckURL(value); err != nil {
+				response.Fail(c, nil, err.Error())
+				return
+			}
+		}
+		updates[image.column] = value
+	}
+	if request.Handle != nil {
+		handle := service.NormalizeHandle(*request.Handle)
+		if handle == "" {
+			updates["handle"] = gorm.Expr("NULL")
+		} else if err := service.CheckHandle(db, handle, user.(model.User).ID); err != nil {
+			response.Fail(c, nil, err.Error())
+			return
+		} else {
+			updates["handle"] = handle
+		}
+	}
+	if request.SocialLinks != nil {
+		links := model.Links{}
+		for platform, link := range *request.SocialLinks {
+			if !contains(model.SocialPlatforms, platform) {
+				response.Fail(c, nil, "不支持的社交平台："+platform)
+				return
+			}
+			link = strings.TrimSpace(link)
+			if link == "" {
+				continue
+			}
+			if err := service.CheckURL(link); err != nil {
+				response.Fail(c, nil, platform+" "+err.Error())
+				return
+			}
+			links[platform] = link
+		}
+		updates["social_links"] = links
+	}
+	if len(updates) == 0 {
+		response.Fail(c, nil, "没有需要修改的内容")
+		return
+	}
+	// 主页标识的唯一性最终由数据库的唯一索引保证
+	if err := db.Model(&model.User{}).Where("id = ?", user.(model.User).ID).Updates(updates).Error; err != nil {
+		if request.Handle != nil && strings.Contains(err.Error(), "Duplicate") {
+			response.Fail(c, nil, service.ErrHandleTaken.Error())
+			return
+		}
 		response.Fail(c, nil, "更新失败")
 		return
 	}
-	response.Success(c, nil, "更新成功")
+	var updated model.User
+	db.Where("id = ?", user.(model.User).ID).First(&updated)
+	response.Success(c, profileFields(db, updated, updated.ID), "更新成功")
 }
 
-// ModifyName 修改用户名
-func ModifyName(c *gin.Context) {
+// GetProfile 根据个人主页标识查询用户资料
+func GetProfile(c *gin.Context) {
 	db := common.GetDB()
-	// 获取用户ID
 	user, _ := c.Get("user")
-	// 获取参数
-	var requestUser model.User
-	c.Bind(&requestUser)
-	userName := requestUser.UserName
-	// 查找用户
 	var curUser model.User
-	db.Where("id = ?", user.(model.User).ID).First(&curUser)
-	// 更新信息
-	if err := db.Model(&curUser).Update("user_name", userName).Error; err != nil {
-		response.Fail(c, nil, "更新失败")
+	if db.Where("handle = ?", service.NormalizeHandle(c.Params.ByName("handle"))).First(&curUser).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
 		return
 	}
-	response.Success(c, nil, "更新成功")
+	profile := profileFields(db, curUser, user.(model.User).ID)
+	profile["loginId"] = user.(model.User).ID
+	response.Success(c, profile, "查找成功")
+}
+
+// PublicProfile 根据个人主页标识查询公开的用户资料，未登录的访问者也可以查看
+func PublicProfile(c *gin.Context) {
+	db := common.GetDB()
+	var curUser model.User
+	if db.Where("handle = ?", service.NormalizeHandle(c.Params.ByName("handle"))).First(&curUser).RecordNotFound() {
+		response.Fail(c, nil, "用户不存在")
+		return
+	}
+	response.Success(c, profileFields(db, curUser, viewerId(c)), "查找成功")
+}
+
+// profileFields 返回访问者可以看到的用户资料，个人资料不可见时只返回用户名、头像等基本信息
+func profileFields(db *gorm.DB, user model.User, viewerId uint) gin.H {
+	profile := gin.H{
+		"id":         user.ID,
+		"name":       user.UserName,
+		"avatar":     user.Avatar,
+		"handle":     user.Handle,
+		"fans":       user.Fans,
+		"created_at": model.Time(user.CreatedAt),
+	}
+	if !service.CanView(db, viewerId, user, user.ProfileVisibility) {
+		profile["profile_hidden"] = true
+		return profile
+	}
+	links := user.SocialLinks
+	if links == nil {
+		links = model.Links{}
+	}
+	profile["bio"] = user.Bio
+	profile["location"] = user.Location
+	profile["website"] = user.Website
+	profile["social_links"] = links
+	profile["cover_image"] = user.CoverImage
+	return profile
 }
 
 // Collects 查询收藏
@@ -267,6 +584,13 @@ func NewCollect(c *gin.Context) {
 	// 查找用户
 	var curUser model.User
 	db.Where("id = ?", user.(model.User).ID).First(&curUser)
+	// 被文章作者屏蔽时不能收藏
+	var article model.Article
+	found := !db.Where("id = ?", id).First(&article).RecordNotFound()
+	if found && service.IsBlocked(db, article.UserId, curUser.ID) {
+		response.Fail(c, nil, "对方已将你屏蔽")
+		return
+	}
 	var newCollects []string
 	newCollects = append(curUser.Collects, id)
 	// 更新收藏夹
@@ -274,6 +598,10 @@ func NewCollect(c *gin.Context) {
 		response.Fail(c, nil, "更新失败")
 		return
 	}
+	// 通知文章作者
+	if found {
+		service.Notify(db, article.UserId, curUser.ID, model.NotifyCollect, id, curUser.UserName+" 收藏了你的文章《"+article.Title+"》")
+	}
 	response.Success(c, nil, "更新成功")
 }
 
@@ -326,6 +654,12 @@ func NewFollow(c *gin.Context) {
 	// 查找用户
 	var curUser model.User
 	db.Where("id = ?", user.(model.User).ID).First(&curUser)
+	// 被对方屏蔽时不能关注
+	followId, _ := strconv.Atoi(id)
+	if service.IsBlocked(db, uint(followId), curUser.ID) {
+		response.Fail(c, nil, "对方已将你屏蔽")
+		return
+	}
 	//var newFollowing []string
 	newFollowing := append(curUser.Following, id)
 	// 更新关注列表
@@ -340,6 +674,8 @@ func NewFollow(c *gin.Context) {
 		response.Fail(c, nil, "更新失败")
 		return
 	}
+	// 通知被关注的用户
+	service.Notify(db, followUser.ID, curUser.ID, model.NotifyFollow, id, curUser.UserName+" 关注了你")
 	response.Success(c, nil, "更新成功")
 }
 
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"blog_server/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"regexp"
+)
+
+// phonePattern 是手机号的格式，允许带国际区号的 6 到 15 位数字。
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// VerificationController 结构体用于处理短信验证码相关的请求。
+type VerificationController struct {
+	DB *gorm.DB
+}
+
+// IVerificationController 接口定义了验证码控制器需要实现的一系列方法。
+type IVerificationController interface {
+	Send(c *gin.Context)   // 发送验证码
+	Verify(c *gin.Context) // 校验验证码
+}
+
+// Send 向手机号发送验证码。
+func (v VerificationController) Send(c *gin.Context) {
+	var request vo.SendCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if !phonePattern.MatchString(request.PhoneNumber) {
+		response.Fail(c, nil, "手机号格式错误")
+		return
+	}
+	if !contains(model.VerificationPurposes, request.Purpose) {
+		response.Fail(c, nil, "验证码用途错误")
+		return
+	}
+	// 注册时手机号不能已被使用
+	if request.Purpose == model.VerifyRegister &&
+		!v.DB.Where("phone_number = ?", request.PhoneNumber).First(&model.User{}).RecordNotFound() {
+		response.Fail(c, nil, "用户已存在")
+		return
+	}
+	// 重置密码时手机号未注册也返回成功，避免借此探测手机号是否注册
+	if request.Purpose == model.VerifyReset &&
+		v.DB.Where("phone_number = ?", request.PhoneNumber).First(&model.User{}).RecordNotFound() {
+		response.Success(c, nil, "验证码已发送")
+		return
+	}
+	if err := service.SendCode(v.DB, request.PhoneNumber, request.Purpose); err != nil {
+		if e, ok := err.(service.ResendError); ok {
+			response.TooManyRequests(c, e.RetryAfter)
+		} else if err == service.ErrCodeDailyLimit {
+			response.Fail(c, nil, err.Error())
+		} else {
+			log.Println("send verification code failed:", err)
+			response.Fail(c, nil, "验证码发送失败")
+		}
+		return
+	}
+	response.Success(c, nil, "验证码已发送")
+}
+
+// Verify 校验手机号收到的验证码，通过后可以在一段时间内完成注册等操作。
+func (v VerificationController) Verify(c *gin.Context) {
+	var request vo.VerifyCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据验证错误")
+		return
+	}
+	if err := service.VerifyCode(v.DB, request.PhoneNumber, request.Purpose, request.Code); err != nil {
+		response.Fail(c, nil, err.Error())
+		return
+	}
+	response.Success(c, nil, "验证成功")
+}
+
+// NewVerificationController 函数用于创建并初始化 VerificationController 实例。
+func NewVerificationController() IVerificationController {
+	db := common.GetDB()
+	db.AutoMigrate(model.VerificationCode{})
+	return &VerificationController{DB: db}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/response"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"strconv"
+	"strings"
+)
+
+// WordController 结构体用于管理员维护敏感词库。
+// 敏感词的增删会立即重新加载到匹配器中，无需重启服务。
+type WordController struct {
+	DB *gorm.DB
+}
+
+// IWordController 接口定义了敏感词控制器需要实现的一系列方法。
+type IWordController interface {
+	List(c *gin.Context)   // 查询敏感词
+	Create(c *gin.Context) // 批量添加敏感词
+	Delete(c *gin.Context) // 删除敏感词
+	Reload(c *gin.Context) // 重新加载敏感词库
+}
+
+// List 分页查询敏感词，可按关键字搜索。
+func (w WordController) List(c *gin.Context) {
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	query := w.DB.Model(model.SensitiveWord{})
+	if keyword := c.Query("keyword"); keyword != "" {
+		query = query.Where("word LIKE ?", "%"+keyword+"%")
+	}
+	var words []model.SensitiveWord
+	var count int
+	query.Order("id desc").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&words)
+	query.Count(&count)
+	response.Success(c, gin.H{"words": words, "count": count}, "查找成功")
+}
+
+// Create 批量添加敏感词，已存在的敏感词会更新处理方式。
+func (w WordController) Create(c *gin.Context) {
+	var request struct {
+		Words  []string `json:"words" binding:"required"`
+		Action string   `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.Fail(c, nil, "数据错误")
+		return
+	}
+	if request.Action != model.WordMask && request.Action != model.WordReview && request.Action != model.WordReject {
+		response.Fail(c, nil, "处理方式错误")
+		return
+	}
+	tx := w.DB.Begin()
+	added := 0
+	for _, word := range request.Words {
+		word = strings.TrimSpace(word)
+		if word == "" || len([]rune(word)) > 50 {
+			continue
+		}
+		var existing model.SensitiveWord
+		var err error
+		if tx.Where("word = ?", word).First(&existing).RecordNotFound() {
+			err = tx.Create(&model.SensitiveWord{Word: word, Action: request.Action}).Error
+		} else {
+			err = tx.Model(&existing).Update("action", request.Action).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			response.Fail(c, nil, "添加失败")
+			return
+		}
+		added++
+	}
+	tx.Commit()
+	user, _ := c.Get("user")
+	service.Audit(w.DB, c, user.(model.User).ID, model.AuditWordCreate, "word", "", nil, request)
+	if err := service.ReloadFilter(w.DB); err != nil {
+		response.Fail(c, nil, "重新加载失败")
+		return
+	}
+	response.Success(c, gin.H{"added": added}, "添加成功")
+}
+
+// Delete 删除敏感词。
+func (w WordController) Delete(c *gin.Context) {
+	var word model.SensitiveWord
+	if w.DB.Where("id = ?", c.Params.ByName("id")).First(&word).RecordNotFound() {
+		response.Fail(c, nil, "敏感词不存在")
+		return
+	}
+	if err := w.DB.Delete(&word).Error; err != nil {
+		response.Fail(c, nil, "删除失败")
+		return
+	}
+	user, _ := c.Get("user")
+	service.Audit(w.DB, c, user.(model.User).ID, model.AuditWordDelete, "word", strconv.Itoa(int(word.ID)), word, nil)
+	if err := service.ReloadFilter(w.DB); err != nil {
+		response.Fail(c, nil, "重新加载失败")
+		return
+	}
+	response.Success(c, nil, "删除成功")
+}
+
+// Reload 从数据库重新加载敏感词库，用于直接修改数据库后生效。
+func (w WordController) Reload(c *gin.Context) {
+	if err := service.ReloadFilter(w.DB); err != nil {
+		response.Fail(c, nil, "重新加载失败")
+		return
+	}
+	response.Success(c, nil, "重新加载成功")
+}
+
+// NewWordController 函数用于创建并初始化 WordController 实例，并加载敏感词库。
+func NewWordController() IWordController {
+	db := common.GetDB()
+	db.AutoMigrate(model.SensitiveWord{})
+	service.ReloadFilter(db)
+	return &WordController{DB: db}
+}
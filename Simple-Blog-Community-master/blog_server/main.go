@@ -3,9 +3,12 @@ package main
 import (
 	"blog_server/common"
 	"blog_server/routes"
+	"blog_server/service"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"net/http"
+	"os"
+	"strings"
 )
 
 func main() {
@@ -13,12 +16,48 @@ func main() {
 	db := common.InitDB()
 	// 延迟关闭数据库
 	defer db.Close()
+	// 启动浏览量计数器，退出前写入剩余的浏览量
+	views := service.InitViewCounter(db)
+	defer views.Stop()
+	// 配置限流和登录锁定的存储，设置 REDIS_ADDR 时多个服务实例共享状态，否则使用内存
+	service.InitRateStore(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"))
+	// 配置短信发送渠道，SMS_SENDER 为 "file:<路径>" 时写入文件，否则输出到日志
+	service.InitSMSSender(os.Getenv("SMS_SENDER"))
+	// 配置邮件发送渠道，MAILER 为 "smtp://用户名:密码@主机:端口" 或 "file:<路径>"，否则输出到日志
+	service.InitMailer(os.Getenv("MAILER"), os.Getenv("MAIL_FROM"))
 	// 创建路由引擎
 	r := gin.Default()
+	// 只信任 TRUSTED_PROXIES 中的反向代理转发的 X-Forwarded-For，默认不信任任何代理，
+	// 避免客户端伪造 IP 绕过按 IP 的限流和登录锁定
+	if err := r.SetTrustedProxies(trustedProxies(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		panic("TRUSTED_PROXIES 配置错误: " + err.Error())
+	}
 	// 配置静态文件路径
 	r.StaticFS("/images", http.Dir("./static/images"))
 	// 启动路由
 	routes.CollectRoutes(r)
+	// 数据表迁移完成后启动排行榜与相关文章的定期计算
+	service.InitRanker(db)
+	service.InitRecommender(db)
+	// 启动封禁到期的自动解封
+	service.InitReinstater(db)
+	// 启动注销和过期会话的定期清理
+	service.InitSessionCleaner(db)
+	// 启动冷静期结束后的账号注销、个人数据导出的后台生成以及文章的批量导入
+	service.InitAccountCloser(db)
+	service.InitExporter(db)
+	service.InitImporter(db)
 	// 启动服务
 	panic(r.Run(":8080"))
 }
+
+// trustedProxies 解析以逗号分隔的代理 IP 或网段列表，为空时返回 nil。
+func trustedProxies(value string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -4,12 +4,20 @@ package middleware
 import (
 	"blog_server/common"
 	"blog_server/model"
+	"blog_server/service"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
 )
 
-// AuthMiddleware 是一个 Gin 中间件，用于验证请求中的 JWT Token。
+// credential 是请求携带的身份凭证：登录 token 对应的会话，或者个人访问令牌。
+type credential struct {
+	user    model.User
+	session model.Session     // 使用登录 token 时的会话
+	token   model.AccessToken // 使用个人访问令牌时的令牌
+}
+
+// AuthMiddleware 是一个 Gin 中间件，用于验证请求中的 JWT Token 或个人访问令牌。
 func AuthMiddleware() gin.HandlerFunc {
 	// 返回一个 Gin 的 HandlerFunc，用于中间件的实际处理。
 	return func(c *gin.Context) {
@@ -26,8 +34,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 如果 Authorization 不合法（不包含 "Bearer" 前缀或长度不足），返回 401 状态码和错误信息。
-		if tokenString == "" || len(tokenString) < 7 || !strings.HasPrefix(tokenString, "Bearer") {
+		// 解析 token 并查询对应的用户和会话（或个人访问令牌），失败或已注销时返回 401 状态码和错误信息。
+		cred, ok := parseCredential(tokenString)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code": 401,
 				"msg":  "权限不足",
@@ -35,37 +44,114 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		user := cred.user
 
-		// 提取 Authorization 字符串中的 token 部分，即 "Bearer" 后面的内容。
-		tokenString = tokenString[7:]
-
-		// 使用 common 包中的 ParseToken 函数解析 token。
-		token, claims, err := common.ParseToken(tokenString)
-
-		// 如果 token 解析失败或 token 无效，返回 401 状态码和错误信息。
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code": 401,
-				"msg":  "权限不足",
+		// 被封禁的用户返回 403 状态码和封禁信息。
+		if user.IsSuspended() {
+			service.AuditThrottled(common.GetDB(), c, service.SuspendedAuditWindow, user.ID, model.AuditSuspendedLogin, "route", c.FullPath(), nil, nil)
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": 403,
+				"msg":  service.SuspensionMessage(user),
 			})
 			c.Abort()
 			return
 		}
 
-		// 从解析后的 claims 中获取 userId。
-		userId := claims.UserId
+		// 个人访问令牌只能访问其权限范围内的接口。
+		if cred.token.ID != 0 && !tokenAllowed(cred.token, c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": 403,
+				"msg":  "访问令牌的权限范围不足",
+			})
+			c.Abort()
+			return
+		}
 
-		// 获取数据库连接实例。
-		DB := common.GetDB()
+		// 将查询到的用户信息和凭证存储到 Gin 上下文中，以便后续处理函数可以访问。
+		cred.store(c)
 
-		// 查询数据库，根据 userId 获取用户信息。
-		var user model.User
-		DB.Where("id = ?", userId).First(&user)
+		// 执行后续的处理函数。
+		c.Next()
+	}
+}
 
-		// 将查询到的用户信息存储到 Gin 上下文中，以便后续处理函数可以访问。
-		c.Set("user", user)
+// OptionalAuthMiddleware 用于允许匿名访问的接口：请求携带有效 token 时将用户存入上下文，
+// 否则直接放行，由后续处理函数自行判断是否登录。被封禁的用户以及权限范围不足的个人访问令牌按匿名访问处理。
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cred, ok := parseCredential(c.Request.Header.Get("Authorization"))
+		if ok && !cred.user.IsSuspended() && (cred.token.ID == 0 || tokenAllowed(cred.token, c)) {
+			cred.store(c)
+		}
+		c.Next()
+	}
+}
 
-		// 执行后续的处理函数。
+// StreamTicketMiddleware 用于 EventSource 等无法设置请求头的场景，
+// 请求未携带 Authorization 请求头时，使用查询参数 ticket 对应的一次性票据中保存的凭证，票据由 POST /events/ticket 签发。
+func StreamTicketMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Authorization") == "" && c.Query("ticket") != "" {
+			if authorization, ok := service.RedeemStreamTicket(c.Query("ticket")); ok {
+				c.Request.Header.Set("Authorization", authorization)
+			}
+		}
 		c.Next()
 	}
 }
+
+// store 将用户和凭证存入上下文，并记录凭证的最近使用时间。
+func (cred credential) store(c *gin.Context) {
+	c.Set("user", cred.user)
+	if cred.token.ID != 0 {
+		c.Set("access_token", cred.token)
+		service.TouchAccessToken(common.GetDB(), cred.token, c.ClientIP())
+		return
+	}
+	c.Set("session", cred.session)
+	service.TouchSession(common.GetDB(), cred.session)
+}
+
+// parseCredential 解析 Authorization 字段中的 token，并返回对应的用户和会话或个人访问令牌。
+func parseCredential(tokenString string) (credential, bool) {
+	var cred credential
+
+	// 如果 Authorization 不合法（不包含 "Bearer" 前缀或长度不足），视为无效。
+	if len(tokenString) < 7 || !strings.HasPrefix(tokenString, "Bearer") {
+		return cred, false
+	}
+	tokenString = tokenString[7:]
+
+	// 个人访问令牌以 pat_ 开头，查询有效的令牌及其所属用户。
+	if strings.HasPrefix(tokenString, model.AccessTokenPrefix) {
+		token, ok := service.FindAccessToken(common.GetDB(), tokenString)
+		if !ok {
+			return cred, false
+		}
+		cred.token = token
+		common.GetDB().Where("id = ?", token.UserId).First(&cred.user)
+		return cred, cred.user.ID != 0
+	}
+
+	// 解析登录 token。
+	token, claims, err := common.ParseToken(tokenString)
+	if err != nil || !token.Valid {
+		return cred, false
+	}
+
+	// token 对应的会话必须存在且有效，没有会话 ID 的旧 token 同样视为无效。
+	if claims.Id == "" {
+		return cred, false
+	}
+	common.GetDB().Where("id = ? AND user_id = ?", claims.Id, claims.UserId).First(&cred.session)
+	if cred.session.ID == "" || !cred.session.Active() {
+		return cred, false
+	}
+
+	// 根据 claims 中的 userId 查询用户信息，修改密码之前发放的 token 视为无效。
+	common.GetDB().Where("id = ?", claims.UserId).First(&cred.user)
+	if cred.user.ID == 0 || cred.user.TokenVersion != claims.TokenVersion {
+		return cred, false
+	}
+	return cred, true
+}
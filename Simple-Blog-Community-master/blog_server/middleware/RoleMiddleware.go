@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"blog_server/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RoleMiddleware 要求登录用户具有指定角色之一，需要在 AuthMiddleware 之后使用。
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		for _, role := range roles {
+			if user != nil && user.(model.User).Role == role {
+				c.Next()
+				return
+			}
+		}
+		// 记录越权访问
+		var userId uint
+		if user != nil {
+			userId = user.(model.User).ID
+		}
+		service.Audit(common.GetDB(), c, userId, model.AuditAccessDenied, "route", c.Request.Method+" "+c.FullPath(), nil, nil)
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "权限不足",
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"blog_server/model"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenRouteScopes 列出了个人访问令牌可以访问的接口及所需的权限范围，键为请求方法和路由。
+// 不在列表中的接口（修改密码、会话管理、令牌管理、管理后台等）只能使用登录 token 访问。
+var tokenRouteScopes = map[string]string{
+	"GET /user":                    model.ScopeRead,
+	"GET /user/briefInfo/:id":      model.ScopeRead,
+	"GET /user/detailedInfo/:id":   model.ScopeRead,
+	"GET /user/handle/:handle":     model.ScopeRead,
+	"GET /user/bookmarks/:id":      model.ScopeRead,
+	"GET /user/following/:id":      model.ScopeRead,
+	"GET /user/followers/:id":      model.ScopeRead,
+	"GET /profiles/handle/:handle": model.ScopeRead,
+	"GET /profiles/:id":            model.ScopeRead,
+	"GET /profiles/:id/detail":     model.ScopeRead,
+	"GET /profiles/:id/bookmarks":  model.ScopeRead,
+	"GET /profiles/:id/following":  model.ScopeRead,
+	"GET /profiles/:id/followers":  model.ScopeRead,
+	"GET /collects/:id":            model.ScopeRead,
+	"GET /following/:id":           model.ScopeRead,
+	"GET /likes/:id":               model.ScopeRead,
+	"GET /likes/user/:id":          model.ScopeRead,
+	"GET /notifications":           model.ScopeRead,
+	"GET /notifications/unread":    model.ScopeRead,
+	"GET /events":                  model.ScopeRead,
+	"POST /events/ticket":          model.ScopeRead,
+	"GET /article/:id":             model.ScopeRead,
+	"POST /article/list":           model.ScopeRead,
+	"GET /article/rank/:kind":      model.ScopeRead,
+	"GET /article/related/:id":     model.ScopeRead,
+	"POST /article":                model.ScopeArticlesWrite,
+	"PUT /article/:id":             model.ScopeArticlesWrite,
+	"DELETE /article/:id":          model.ScopeArticlesWrite,
+	"GET /user/imports":            model.ScopeArticlesWrite,
+	"GET /user/imports/:id":        model.ScopeArticlesWrite,
+	"POST /user/imports":           model.ScopeArticlesWrite,
+	"PUT /collects/new/:id":        model.ScopeSocialWrite,
+	"DELETE /collects/:index":      model.ScopeSocialWrite,
+	"PUT /following/new/:id":       model.ScopeSocialWrite,
+	"DELETE /following/:index":     model.ScopeSocialWrite,
+	"PUT /likes/new/:id":           model.ScopeSocialWrite,
+	"DELETE /likes/:id":            model.ScopeSocialWrite,
+	"PUT /notifications/read/:id":  model.ScopeSocialWrite,
+	"PUT /notifications/read":      model.ScopeSocialWrite,
+	"GET /messages":                model.ScopeMessages,
+	"GET /messages/:id":            model.ScopeMessages,
+	"POST /messages/:id":           model.ScopeMessages,
+	"PUT /messages/read/:id":       model.ScopeMessages,
+}
+
+// tokenAllowed 判断个人访问令牌是否可以访问当前接口。
+func tokenAllowed(token model.AccessToken, c *gin.Context) bool {
+	scope, ok := tokenRouteScopes[c.Request.Method+" "+c.FullPath()]
+	return ok && token.HasScope(scope)
+}
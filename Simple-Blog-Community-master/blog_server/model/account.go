@@ -0,0 +1,37 @@
+package model
+
+// model/account.go
+
+// 注销账号时对文章的处理方式
+const (
+	DeletionAnonymize = "anonymize" // 保留文章，作者显示为已注销用户
+	DeletionRemove    = "remove"    // 删除文章
+)
+
+// DeletionModes 列出了注销账号时可以选择的文章处理方式。
+var DeletionModes = []string{DeletionAnonymize, DeletionRemove}
+
+// ClosedUserName 是注销后的账号显示的用户名。
+const ClosedUserName = "已注销用户"
+
+// 数据导出任务的状态
+const (
+	ExportPending = "pending" // 等待生成
+	ExportRunning = "running" // 正在生成
+	ExportDone    = "done"    // 已生成，可以下载
+	ExportFailed  = "failed"  // 生成失败
+	ExportExpired = "expired" // 已过期，文件已删除
+)
+
+// DataExport 定义了个人数据导出任务，导出的文件为包含个人资料、文章、收藏和关注列表的 ZIP 压缩包。
+type DataExport struct {
+	ID         uint   `json:"id" gorm:"primary_key"`
+	UserId     uint   `json:"-" gorm:"not null;index"`                       // 所属用户 ID。
+	Status     string `json:"status" gorm:"type:varchar(10);not null;index"` // 任务状态。
+	File       string `json:"-" gorm:"type:varchar(255)"`                    // 生成的文件路径。
+	Size       int64  `json:"size"`                                          // 文件大小，单位为字节。
+	Error      string `json:"error" gorm:"type:varchar(255)"`                // 生成失败的原因。
+	FinishedAt *Time  `json:"finished_at" gorm:"type:timestamp NULL"`        // 生成完成的时间。
+	ExpiresAt  *Time  `json:"expires_at" gorm:"type:timestamp NULL"`         // 文件的过期时间，过期后需要重新导出。
+	CreatedAt  Time   `json:"created_at" gorm:"type:timestamp"`              // 申请导出的时间。
+}
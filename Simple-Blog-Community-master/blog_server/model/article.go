@@ -15,6 +15,9 @@ type Article struct {
 	Title      string    `json:"title" gorm:"type:varchar(50);not null"` // 文章标题，最大长度为 50。
 	Content    string    `json:"content" gorm:"type:text;not null"`      // 文章内容。
 	HeadImage  string    `json:"head_image"`                             // 文章头图的链接或路径。
+	LikeCount  int       `json:"like_count" gorm:"not null;default:0"`   // 文章的点赞数。
+	ViewCount  int       `json:"view_count" gorm:"not null;default:0"`   // 文章的浏览数。
+	Hidden     bool      `json:"hidden" gorm:"not null;default:false"`   // 文章是否被版主隐藏。
 	CreatedAt  Time      `json:"created_at" gorm:"type:timestamp"`       // 文章创建时间。
 	UpdatedAt  Time      `json:"updated_at" gorm:"type:timestamp"`       // 文章更新时间。
 
@@ -23,10 +26,14 @@ type Article struct {
 // ArticleInfo 定义了用于传输的文章信息，可能是用于 API 响应。
 type ArticleInfo struct {
 	ID         string `json:"id"`          // 文章 ID，作为字符串传输。
+	UserId     uint   `json:"user_id"`     // 文章作者的用户 ID。
 	CategoryId uint   `json:"category_id"` // 文章所属分类的 ID。
 	Title      string `json:"title"`       // 文章标题。
 	Content    string `json:"content"`     // 文章内容。
 	HeadImage  string `json:"head_image"`  // 文章头图的链接或路径。
+	LikeCount  int    `json:"like_count"`  // 文章的点赞数。
+	ViewCount  int    `json:"view_count"`  // 文章的浏览数。
+	Liked      bool   `json:"liked"`       // 当前登录用户是否已点赞。
 	CreatedAt  Time   `json:"created_at"`  // 文章创建时间。
 
 }
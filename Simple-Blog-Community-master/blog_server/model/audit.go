@@ -0,0 +1,67 @@
+package model
+
+import "errors"
+
+// model/audit.go
+
+// 审计日志记录的操作
+const (
+	AuditRegister        = "register"         // 注册
+	AuditLogin           = "login"            // 登录成功
+	AuditLoginFailed     = "login_failed"     // 登录失败
+	AuditArticleDelete   = "article_delete"   // 删除文章
+	AuditReportHandle    = "report_handle"    // 处理举报
+	AuditUserSuspend     = "user_suspend"     // 封禁用户
+	AuditUserReinstate   = "user_reinstate"   // 解除封禁
+	AuditWordCreate      = "word_create"      // 添加敏感词
+	AuditWordDelete      = "word_delete"      // 删除敏感词
+	AuditAccessDenied    = "access_denied"    // 访问无权限的接口
+	AuditSuspendedLogin  = "suspended_login"  // 被封禁的用户尝试登录或访问
+	AuditPasswordChange  = "password_change"  // 修改密码
+	AuditPasswordReset   = "password_reset"   // 通过验证码或邮件重置密码
+	AuditEmailChange     = "email_change"     // 修改邮箱
+	AuditEmailVerify     = "email_verify"     // 验证邮箱
+	AuditTwoFactorOn     = "2fa_enable"       // 开启两步验证
+	AuditTwoFactorOff    = "2fa_disable"      // 关闭两步验证
+	AuditRecoveryUsed    = "2fa_recovery"     // 使用恢复码登录
+	AuditRecoveryReset   = "2fa_codes_reset"  // 重新生成恢复码
+	AuditOAuthLink       = "oauth_link"       // 绑定外部登录账号
+	AuditOAuthUnlink     = "oauth_unlink"     // 解绑外部登录账号
+	AuditLogout          = "logout"           // 退出登录
+	AuditSessionRevoke   = "session_revoke"   // 注销其他会话
+	AuditTokenCreate     = "token_create"     // 创建个人访问令牌
+	AuditTokenRevoke     = "token_revoke"     // 撤销个人访问令牌
+	AuditDeletionRequest = "deletion_request" // 申请注销账号
+	AuditDeletionCancel  = "deletion_cancel"  // 撤销注销申请
+	AuditAccountClose    = "account_close"    // 冷静期结束后完成注销
+	AuditDataExport      = "data_export"      // 申请导出个人数据
+)
+
+// errAppendOnly 在尝试修改或删除审计日志时返回。
+var errAppendOnly = errors.New("audit logs are append-only")
+
+// AuditLog 定义了安全相关操作和管理操作的审计日志，只能追加，不能修改或删除。
+// 下面的 GORM 钩子只能拦截通过模型进行的修改，db.Exec 等原生 SQL 不经过钩子，
+// 因此 NewAuditController 还会在数据库中创建拒绝 UPDATE 和 DELETE 的触发器。
+type AuditLog struct {
+	ID         uint   `json:"id" gorm:"primary_key"`
+	ActorId    uint   `json:"actor_id" gorm:"index"`                         // 操作者的用户 ID，未登录时为 0。
+	Action     string `json:"action" gorm:"type:varchar(50);not null;index"` // 操作类型。
+	TargetType string `json:"target_type" gorm:"type:varchar(20)"`           // 操作对象的类型。
+	TargetId   string `json:"target_id" gorm:"type:varchar(64)"`             // 操作对象的 ID。
+	IP         string `json:"ip" gorm:"type:varchar(45)"`                    // 客户端 IP。
+	UserAgent  string `json:"user_agent" gorm:"type:varchar(255)"`           // 客户端 User-Agent。
+	Before     string `json:"before" gorm:"type:text"`                       // 操作前的快照（JSON）。
+	After      string `json:"after" gorm:"type:text"`                        // 操作后的快照（JSON）。
+	CreatedAt  Time   `json:"created_at" gorm:"type:timestamp;index"`        // 操作时间。
+}
+
+// BeforeUpdate 是 GORM 的钩子方法，禁止修改审计日志。
+func (a *AuditLog) BeforeUpdate() error {
+	return errAppendOnly
+}
+
+// BeforeDelete 是 GORM 的钩子方法，禁止删除审计日志。
+func (a *AuditLog) BeforeDelete() error {
+	return errAppendOnly
+}
@@ -0,0 +1,20 @@
+package model
+
+// model/block.go
+
+// Block 定义了用户之间的屏蔽关系。被屏蔽的用户无法关注屏蔽者或给其发送私信，
+// 其文章也不会出现在屏蔽者的文章列表中。
+type Block struct {
+	ID        uint `json:"id" gorm:"primary_key"`
+	UserId    uint `json:"user_id" gorm:"not null;unique_index:idx_block_user_blocked"`    // 屏蔽者的用户 ID。
+	BlockedId uint `json:"blocked_id" gorm:"not null;unique_index:idx_block_user_blocked"` // 被屏蔽的用户 ID。
+	CreatedAt Time `json:"created_at" gorm:"type:timestamp"`                               // 屏蔽时间。
+}
+
+// Mute 定义了用户之间的静音关系，被静音用户的文章不会出现在静音者的文章列表和动态中。
+type Mute struct {
+	ID        uint `json:"id" gorm:"primary_key"`
+	UserId    uint `json:"user_id" gorm:"not null;unique_index:idx_mute_user_muted"`  // 静音者的用户 ID。
+	MutedId   uint `json:"muted_id" gorm:"not null;unique_index:idx_mute_user_muted"` // 被静音的用户 ID。
+	CreatedAt Time `json:"created_at" gorm:"type:timestamp"`                          // 静音时间。
+}
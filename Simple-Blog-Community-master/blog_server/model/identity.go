@@ -0,0 +1,24 @@
+package model
+
+import "strings"
+
+// model/identity.go
+
+// PlaceholderPhonePrefix 是通过外部登录创建的账号使用的占位手机号前缀。
+// 手机号是必填且唯一的，这类账号在绑定真实手机号之前使用随机的占位值。
+const PlaceholderPhonePrefix = "oauth_"
+
+// ExternalIdentity 记录用户绑定的外部登录账号。
+type ExternalIdentity struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	UserId    uint   `json:"user_id" gorm:"not null;index"`                                               // 绑定的用户 ID。
+	Provider  string `json:"provider" gorm:"type:varchar(30);not null;unique_index:idx_identity_subject"` // 外部登录服务的标识。
+	Subject   string `json:"subject" gorm:"type:varchar(191);not null;unique_index:idx_identity_subject"` // 外部账号的唯一标识。
+	Email     string `json:"email" gorm:"type:varchar(100)"`                                              // 绑定时外部账号的邮箱。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp"`                                            // 绑定时间。
+}
+
+// HasPlaceholderPhone 判断用户是否使用占位手机号，即通过外部登录创建且尚未绑定真实手机号。
+func (u User) HasPlaceholderPhone() bool {
+	return strings.HasPrefix(u.PhoneNumber, PlaceholderPhonePrefix)
+}
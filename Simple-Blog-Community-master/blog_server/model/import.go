@@ -0,0 +1,54 @@
+package model
+
+// model/import.go
+
+// 导入文件的来源格式
+const (
+	ImportMarkdown  = "markdown"  // 包含带 front matter 的 Markdown 文件的 ZIP 压缩包，兼容 Hexo 和 Jekyll
+	ImportWordPress = "wordpress" // WordPress 导出的 WXR 文件
+)
+
+// 导入任务的状态
+const (
+	ImportPending = "pending" // 等待导入
+	ImportRunning = "running" // 正在导入
+	ImportDone    = "done"    // 导入完成，各篇文章的结果见 ImportItem
+	ImportFailed  = "failed"  // 文件无法解析，没有导入任何文章
+)
+
+// 单篇文章的导入结果
+const (
+	ImportItemImported = "imported" // 已导入
+	ImportItemSkipped  = "skipped"  // 草稿或已存在同名文章，未导入
+	ImportItemFailed   = "failed"   // 导入失败
+)
+
+// ImportJob 定义了批量导入文章的任务，上传的文件在后台解析并逐篇导入。
+type ImportJob struct {
+	ID         uint   `json:"id" gorm:"primary_key"`
+	UserId     uint   `json:"-" gorm:"not null;index"`                       // 导入文章的用户 ID。
+	Source     string `json:"source" gorm:"type:varchar(10);not null"`       // 导入文件的格式。
+	FileName   string `json:"file_name" gorm:"type:varchar(255)"`            // 上传的文件名。
+	File       string `json:"-" gorm:"type:varchar(255)"`                    // 上传文件的保存路径，导入完成后删除。
+	CategoryId uint   `json:"category_id" gorm:"not null"`                   // 无法匹配分类的文章使用的默认分类。
+	Status     string `json:"status" gorm:"type:varchar(10);not null;index"` // 任务状态。
+	Total      int    `json:"total"`                                         // 文件中的文章总数。
+	Imported   int    `json:"imported"`                                      // 导入成功的文章数。
+	Skipped    int    `json:"skipped"`                                       // 跳过的文章数。
+	Failed     int    `json:"failed"`                                        // 导入失败的文章数。
+	Error      string `json:"error" gorm:"type:varchar(255)"`                // 文件无法解析的原因。
+	FinishedAt *Time  `json:"finished_at" gorm:"type:timestamp NULL"`        // 导入完成的时间。
+	CreatedAt  Time   `json:"created_at" gorm:"type:timestamp"`              // 上传时间。
+}
+
+// ImportItem 定义了导入任务中单篇文章的导入结果。
+type ImportItem struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	JobId     uint   `json:"-" gorm:"not null;index"`                 // 所属导入任务的 ID。
+	Name      string `json:"name" gorm:"type:varchar(255)"`           // 文章在导入文件中的位置，Markdown 为文件路径，WordPress 为原文链接。
+	Title     string `json:"title" gorm:"type:varchar(255)"`          // 文章标题。
+	Status    string `json:"status" gorm:"type:varchar(10);not null"` // 导入结果。
+	ArticleId string `json:"article_id" gorm:"type:char(36)"`         // 导入成功时创建的文章 ID。
+	Message   string `json:"message" gorm:"type:text"`                // 失败或跳过的原因，以及导入时的提示，例如图片下载失败。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp"`        // 导入时间。
+}
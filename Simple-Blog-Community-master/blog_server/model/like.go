@@ -0,0 +1,11 @@
+package model
+
+// model/like.go
+
+// Like 定义了点赞记录，每个用户对同一篇文章只能点赞一次。
+type Like struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	UserId    uint   `json:"user_id" gorm:"not null;unique_index:idx_like_user_article"`                  // 点赞用户的 ID。
+	ArticleId string `json:"article_id" gorm:"type:char(36);not null;unique_index:idx_like_user_article"` // 被点赞文章的 ID。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp"`                                            // 点赞时间。
+}
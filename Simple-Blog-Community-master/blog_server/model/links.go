@@ -0,0 +1,43 @@
+// model/links.go
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Links 表示用户的社交账号链接，键为平台名称，值为链接地址，以 JSON 存储到数据库中。
+type Links map[string]string
+
+// SocialPlatforms 列出了可以填写的社交平台。
+var SocialPlatforms = []string{"github", "gitee", "weibo", "zhihu", "bilibili", "juejin", "twitter", "linkedin"}
+
+// Scan 方法用于从数据库读取 JSON 字符串并解析为 Links。
+func (l *Links) Scan(val interface{}) error {
+	*l = Links{}
+	var data []byte
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for Links")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
+
+// Value 方法用于将 Links 序列化为 JSON 字符串存储到数据库中。
+func (l Links) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
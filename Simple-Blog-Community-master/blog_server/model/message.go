@@ -0,0 +1,33 @@
+package model
+
+// model/message.go
+
+// Conversation 定义了两个用户之间的私信会话，UserA 始终为较小的用户 ID。
+type Conversation struct {
+	ID            uint `json:"id" gorm:"primary_key"`
+	UserA         uint `json:"user_a" gorm:"not null;unique_index:idx_conversation_users"` // 会话双方中较小的用户 ID。
+	UserB         uint `json:"user_b" gorm:"not null;unique_index:idx_conversation_users"` // 会话双方中较大的用户 ID。
+	LastMessageId uint `json:"last_message_id"`                                            // 最后一条私信的 ID。
+	CreatedAt     Time `json:"created_at" gorm:"type:timestamp"`                           // 会话创建时间。
+	UpdatedAt     Time `json:"updated_at" gorm:"type:timestamp"`                           // 最后一条私信的时间。
+}
+
+// Message 定义了私信的数据模型。
+type Message struct {
+	ID             uint   `json:"id" gorm:"primary_key"`
+	ConversationId uint   `json:"conversation_id" gorm:"not null;index"` // 所属会话的 ID。
+	SenderId       uint   `json:"sender_id" gorm:"not null;index"`       // 发送者的用户 ID。
+	ReceiverId     uint   `json:"receiver_id" gorm:"not null;index"`     // 接收者的用户 ID。
+	Content        string `json:"content" gorm:"type:text;not null"`     // 私信内容。
+	ReadAt         *Time  `json:"read_at" gorm:"type:timestamp NULL"`    // 接收者阅读的时间，为空表示未读。
+	CreatedAt      Time   `json:"created_at" gorm:"type:timestamp"`      // 发送时间。
+}
+
+// ConversationInfo 定义了用于传输的会话信息，包含对方用户、最后一条私信和未读数。
+type ConversationInfo struct {
+	ID          uint     `json:"id"`
+	User        UserInfo `json:"user"`
+	LastMessage *Message `json:"last_message"`
+	Unread      int      `json:"unread"`
+	UpdatedAt   Time     `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+// model/notification.go
+
+// 通知的类型
+const (
+	NotifyFollow  = "follow"  // 被其他用户关注
+	NotifyCollect = "collect" // 文章被收藏
+	NotifyLike    = "like"    // 文章被点赞
+	NotifyReport  = "report"  // 举报的处理结果
+	NotifyWarn    = "warn"    // 版主的警告，不能被屏蔽
+	NotifySystem  = "system"  // 系统通知，例如账号解封，不能被屏蔽
+)
+
+// NotificationTypes 列出了用户可以屏蔽的通知类型，用于校验和展示通知偏好设置。
+var NotificationTypes = []string{NotifyFollow, NotifyCollect, NotifyLike, NotifyReport}
+
+// Notification 定义了站内通知的数据模型。
+type Notification struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	UserId    uint   `json:"user_id" gorm:"not null;index"`          // 接收通知的用户 ID。
+	ActorId   uint   `json:"actor_id"`                               // 触发通知的用户 ID。
+	Type      string `json:"type" gorm:"type:varchar(20);not null"`  // 通知类型。
+	TargetId  string `json:"target_id" gorm:"type:varchar(36)"`      // 通知关联对象的 ID，例如文章 ID。
+	Content   string `json:"content" gorm:"type:varchar(255)"`       // 通知内容。
+	IsRead    bool   `json:"read" gorm:"not null;default:false"`     // 是否已读。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp;index"` // 通知时间。
+}
+
+// NotificationInfo 定义了用于传输的通知信息，附带触发者的用户名和头像。
+type NotificationInfo struct {
+	ID          uint   `json:"id"`
+	ActorId     uint   `json:"actor_id"`
+	ActorName   string `json:"actor_name"`
+	ActorAvatar string `json:"actor_avatar"`
+	Type        string `json:"type"`
+	TargetId    string `json:"target_id"`
+	Content     string `json:"content"`
+	IsRead      bool   `json:"read"`
+	CreatedAt   Time   `json:"created_at"`
+}
+
+// NotificationPreference 记录用户屏蔽的通知类型，存在记录即表示屏蔽该类型的通知。
+type NotificationPreference struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	UserId uint   `json:"user_id" gorm:"not null;unique_index:idx_preference_user_type"`
+	Type   string `json:"type" gorm:"type:varchar(20);not null;unique_index:idx_preference_user_type"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// model/related.go
+
+// RelatedArticle 定义了离线计算得到的相关文章推荐结果。
+type RelatedArticle struct {
+	ID        uint    `json:"id" gorm:"primary_key"`
+	ArticleId string  `json:"article_id" gorm:"type:char(36);not null;index"` // 文章 ID。
+	RelatedId string  `json:"related_id" gorm:"type:char(36);not null"`       // 推荐的相关文章 ID。
+	Score     float64 `json:"score"`                                          // 相关度得分。
+	Position  int     `json:"position"`                                       // 推荐结果中的名次，从 0 开始。
+}
@@ -0,0 +1,48 @@
+package model
+
+// model/report.go
+
+// 举报对象的类型
+const (
+	ReportArticle = "article" // 文章
+	ReportUser    = "user"    // 用户主页
+)
+
+// ReportSensitive 是内容命中敏感词时系统自动提交举报使用的原因。
+const ReportSensitive = "sensitive"
+
+// 举报的原因
+var ReportReasons = []string{"spam", "abuse", "porn", "illegal", "plagiarism", "other"}
+
+// 举报的处理状态
+const (
+	ReportPending   = "pending"   // 待处理
+	ReportResolved  = "resolved"  // 已处理
+	ReportDismissed = "dismissed" // 已驳回
+)
+
+// 版主对举报采取的处理措施
+const (
+	ActionDismiss = "dismiss" // 驳回举报
+	ActionHide    = "hide"    // 隐藏文章
+	ActionDelete  = "delete"  // 删除文章
+	ActionWarn    = "warn"    // 警告作者
+	ActionSuspend = "suspend" // 封禁作者
+)
+
+// Report 定义了举报及其处理结果的数据模型。
+type Report struct {
+	ID           uint   `json:"id" gorm:"primary_key"`
+	ReporterId   uint   `json:"reporter_id" gorm:"not null;index"`                // 举报人的用户 ID。
+	TargetType   string `json:"target_type" gorm:"type:varchar(20);not null"`     // 举报对象的类型。
+	TargetId     string `json:"target_id" gorm:"type:varchar(36);not null;index"` // 举报对象的 ID。
+	TargetUserId uint   `json:"target_user_id" gorm:"not null"`                   // 被举报内容的作者 ID。
+	Reason       string `json:"reason" gorm:"type:varchar(20);not null"`          // 举报原因。
+	Detail       string `json:"detail" gorm:"type:varchar(500)"`                  // 举报的详细说明。
+	Status       string `json:"status" gorm:"type:varchar(20);not null;index"`    // 处理状态。
+	Action       string `json:"action" gorm:"type:varchar(20)"`                   // 采取的处理措施。
+	Note         string `json:"note" gorm:"type:varchar(500)"`                    // 版主的处理备注。
+	ModeratorId  uint   `json:"moderator_id"`                                     // 处理举报的版主 ID。
+	HandledAt    *Time  `json:"handled_at" gorm:"type:timestamp NULL"`            // 处理时间。
+	CreatedAt    Time   `json:"created_at" gorm:"type:timestamp"`                 // 举报时间。
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// model/session.go
+
+// Session 记录一次登录，token 中保存会话 ID，会话被注销后对应的 token 立即失效。
+type Session struct {
+	ID         string `json:"id" gorm:"type:char(36);primary_key"` // 会话 ID，同时作为 token 的 jti。
+	UserId     uint   `json:"-" gorm:"not null;index"`             // 所属用户 ID。
+	Device     string `json:"device" gorm:"type:varchar(50)"`      // 根据 User-Agent 识别的设备，例如 Chrome on Windows。
+	IP         string `json:"ip" gorm:"type:varchar(45)"`          // 登录时的 IP。
+	UserAgent  string `json:"user_agent" gorm:"type:varchar(255)"` // 登录时的 User-Agent。
+	CreatedAt  Time   `json:"created_at" gorm:"type:timestamp"`    // 登录时间。
+	LastSeenAt Time   `json:"last_seen_at" gorm:"type:timestamp"`  // 最近一次使用的时间。
+	ExpiresAt  Time   `json:"expires_at" gorm:"type:timestamp"`    // 与 token 相同的过期时间。
+	RevokedAt  *Time  `json:"-" gorm:"type:timestamp NULL"`        // 注销时间，为空表示有效。
+}
+
+// Active 判断会话是否有效，即未注销且未过期。
+func (s Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(time.Time(s.ExpiresAt))
+}
@@ -56,6 +56,10 @@ func (t Time) Value() (driver.Value, error) {
 
 // Scan 方法实现了数据库驱动的 Scanner 接口，用于从数据库扫描时间值。
 func (t *Time) Scan(v interface{}) error {
+	if v == nil {
+		*t = Time{} // 数据库中的 NULL 对应零时间
+		return nil
+	}
 	value, ok := v.(time.Time) // 断言接口为 time.Time 类型
 	if ok {
 		*t = Time(value) // 更新 Time 类型的值
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// model/token.go
+
+// AccessTokenPrefix 是个人访问令牌的前缀，用于与登录 token 区分。
+const AccessTokenPrefix = "pat_"
+
+// 个人访问令牌的权限范围。
+const (
+	ScopeRead          = "read"           // 只读：查看文章、用户信息、收藏、关注、通知等
+	ScopeArticlesWrite = "articles:write" // 发布、修改、删除文章
+	ScopeSocialWrite   = "social:write"   // 收藏、关注、点赞以及标记通知已读
+	ScopeMessages      = "messages"       // 查看和发送私信
+)
+
+// AccessTokenScopes 列出了可以授予个人访问令牌的权限范围。
+var AccessTokenScopes = []string{ScopeRead, ScopeArticlesWrite, ScopeSocialWrite, ScopeMessages}
+
+// AccessToken 是用户创建的个人访问令牌，用于脚本和持续集成等自动化场景，只保存令牌的哈希值。
+type AccessToken struct {
+	ID         uint   `json:"id" gorm:"primary_key"`
+	UserId     uint   `json:"-" gorm:"not null;index"`                 // 所属用户 ID。
+	Name       string `json:"name" gorm:"type:varchar(50);not null"`   // 令牌名称，用于区分用途。
+	Hint       string `json:"hint" gorm:"type:varchar(12);not null"`   // 令牌的开头部分，便于用户辨认。
+	TokenHash  string `json:"-" gorm:"type:char(64);not null;unique"`  // 令牌的哈希值。
+	Scopes     string `json:"scopes" gorm:"type:varchar(255)"`         // 权限范围，以逗号分隔。
+	LastUsedAt *Time  `json:"last_used_at" gorm:"type:timestamp NULL"` // 最近一次使用的时间。
+	LastUsedIP string `json:"last_used_ip" gorm:"type:varchar(45)"`    // 最近一次使用的 IP。
+	ExpiresAt  *Time  `json:"expires_at" gorm:"type:timestamp NULL"`   // 过期时间，为空表示永不过期。
+	RevokedAt  *Time  `json:"-" gorm:"type:timestamp NULL"`            // 撤销时间，为空表示有效。
+	CreatedAt  Time   `json:"created_at" gorm:"type:timestamp"`        // 创建时间。
+}
+
+// Active 判断令牌是否有效，即未撤销且未过期。
+func (t AccessToken) Active() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || time.Now().Before(time.Time(*t.ExpiresAt)))
+}
+
+// HasScope 判断令牌是否具有指定的权限范围。
+func (t AccessToken) HasScope(scope string) bool {
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
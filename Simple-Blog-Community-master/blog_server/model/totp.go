@@ -0,0 +1,12 @@
+package model
+
+// model/totp.go
+
+// RecoveryCode 是两步验证的一次性恢复码，用于无法使用验证器应用时登录，只保存哈希值。
+type RecoveryCode struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	UserId    uint   `json:"user_id" gorm:"not null;unique_index:uix_recovery_codes_user_code"`         // 所属用户 ID。
+	CodeHash  string `json:"-" gorm:"type:char(64);not null;unique_index:uix_recovery_codes_user_code"` // 恢复码的哈希值，只在同一用户内唯一。
+	UsedAt    *Time  `json:"used_at" gorm:"type:timestamp NULL"`                                        // 使用时间，为空表示未使用。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp"`                                          // 生成时间。
+}
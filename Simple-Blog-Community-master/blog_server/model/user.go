@@ -2,17 +2,78 @@ package model
 
 import (
 	"github.com/jinzhu/gorm"
+	"time"
 )
 
+// 用户的角色
+const (
+	RoleUser      = "user"      // 普通用户
+	RoleModerator = "moderator" // 版主，可以处理举报
+	RoleAdmin     = "admin"     // 管理员
+)
+
+// 隐私设置的可见范围
+const (
+	VisibilityPublic    = "public"    // 所有人可见
+	VisibilityFollowers = "followers" // 仅关注了该用户的人可见
+	VisibilityPrivate   = "private"   // 仅自己可见
+)
+
+// Visibilities 列出了隐私设置可以选择的可见范围。
+var Visibilities = []string{VisibilityPublic, VisibilityFollowers, VisibilityPrivate}
+
 type User struct {
 	gorm.Model
-	UserName    string `gorm:"varchar(20);not null"`
-	PhoneNumber string `gorm:"varchar(20);not null;unique"`
-	Password    string `gorm:"size:255;not null"`
-	Avatar      string `gorm:"size:255;not null"`
-	Collects    Array  `gorm:"type:longtext"`
-	Following   Array  `gorm:"type:longtext"`
-	Fans        int    `gorm:"AUTO_INCREMENT"`
+	UserName            string  `gorm:"varchar(20);not null"`
+	PhoneNumber         string  `gorm:"varchar(20);not null;unique"`
+	PhoneVerified       bool    `gorm:"not null;default:false"`  // 手机号是否通过验证码验证
+	Email               *string `gorm:"type:varchar(100);index"` // 邮箱，可以为空，验证后可以用于登录；只有已验证的邮箱唯一，见 service.MarkEmailVerified
+	EmailVerified       bool    `gorm:"not null;default:false"`  // 邮箱是否通过验证
+	Password            string  `gorm:"size:255;not null"`
+	Avatar              string  `gorm:"size:255;not null"`
+	Handle              *string `gorm:"type:varchar(30);unique_index"` // 个人主页地址中使用的唯一标识，可以为空
+	Bio                 string  `gorm:"size:500"`                      // 个人简介
+	Location            string  `gorm:"size:50"`                       // 所在地
+	Website             string  `gorm:"size:255"`                      // 个人网站
+	SocialLinks         Links   `gorm:"type:text"`                     // 社交账号链接
+	CoverImage          string  `gorm:"size:255"`                      // 个人主页封面图
+	Collects            Array   `gorm:"type:longtext"`
+	Following           Array   `gorm:"type:longtext"`
+	Fans                int     `gorm:"AUTO_INCREMENT"`
+	Role                string  `gorm:"type:varchar(20);not null;default:'user'"`
+	SuspendedUntil      *Time   `gorm:"type:datetime NULL"` // 封禁截止时间，为空表示未被封禁；永久封禁超出 timestamp 的范围，因此使用 datetime
+	SuspendReason       string  `gorm:"size:255"`
+	ContentHidden       bool    `gorm:"not null;default:false"`                     // 封禁期间是否隐藏该用户的文章
+	TokenVersion        int     `gorm:"not null;default:0"`                         // 修改密码时递增，使之前发放的 token 失效
+	TOTPSecret          string  `gorm:"size:64"`                                    // 两步验证的密钥，开启前为待确认的密钥
+	TOTPEnabled         bool    `gorm:"not null;default:false"`                     // 是否开启了两步验证
+	TOTPLastStep        int64   `gorm:"not null;default:0"`                         // 上一次验证通过的时间步，防止动态验证码被重放
+	ProfileVisibility   string  `gorm:"type:varchar(10);not null;default:'public'"` // 个人简介、所在地、网站等资料的可见范围，取值见 Visibilities
+	BookmarksVisibility string  `gorm:"type:varchar(10);not null;default:'public'"` // 收藏列表的可见范围
+	FollowingVisibility string  `gorm:"type:varchar(10);not null;default:'public'"` // 关注列表的可见范围
+	FollowersVisibility string  `gorm:"type:varchar(10);not null;default:'public'"` // 粉丝列表的可见范围
+	DeletionScheduledAt *Time   `gorm:"type:datetime NULL"`                         // 申请注销后账号将被删除的时间，为空表示未申请注销
+	DeletionMode        string  `gorm:"type:varchar(10)"`                           // 注销时对文章的处理方式，取值见 DeletionModes
+	ClosedAt            *Time   `gorm:"type:datetime NULL"`                         // 账号完成注销的时间
+	DeletionError       string  `gorm:"type:varchar(255)"`                          // 冷静期结束后注销失败的原因，下次检查时重试
+}
+
+// PermanentSuspension 是永久封禁使用的截止时间。
+var PermanentSuspension = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// IsClosed 判断账号是否已经注销。
+func (u User) IsClosed() bool {
+	return u.ClosedAt != nil
+}
+
+// IsSuspended 判断用户当前是否处于封禁状态。
+func (u User) IsSuspended() bool {
+	return u.SuspendedUntil != nil && time.Time(*u.SuspendedUntil).After(time.Now())
+}
+
+// IsPermanentlySuspended 判断用户是否被永久封禁。
+func (u User) IsPermanentlySuspended() bool {
+	return u.SuspendedUntil != nil && !time.Time(*u.SuspendedUntil).Before(PermanentSuspension)
 }
 
 type UserInfo struct {
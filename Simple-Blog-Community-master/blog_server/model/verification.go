@@ -0,0 +1,25 @@
+package model
+
+// model/verification.go
+
+// 验证码的用途，不同用途的验证码互不通用。
+const (
+	VerifyRegister = "register" // 注册时验证手机号
+	VerifyReset    = "reset"    // 忘记密码时重置密码
+)
+
+// VerificationPurposes 列出了可以申请验证码的用途。
+var VerificationPurposes = []string{VerifyRegister, VerifyReset}
+
+// VerificationCode 记录发送给手机号的验证码，只保存验证码的哈希值。
+type VerificationCode struct {
+	ID          uint   `json:"id" gorm:"primary_key"`
+	PhoneNumber string `json:"phone_number" gorm:"type:varchar(20);not null;index"` // 接收验证码的手机号。
+	Purpose     string `json:"purpose" gorm:"type:varchar(20);not null"`            // 验证码的用途。
+	CodeHash    string `json:"-" gorm:"type:char(64);not null"`                     // 验证码的哈希值。
+	Attempts    int    `json:"attempts" gorm:"not null;default:0"`                  // 已经尝试验证的次数。
+	ExpiresAt   Time   `json:"expires_at" gorm:"type:timestamp"`                    // 过期时间。
+	VerifiedAt  *Time  `json:"verified_at" gorm:"type:timestamp NULL"`              // 验证通过的时间。
+	UsedAt      *Time  `json:"used_at" gorm:"type:timestamp NULL"`                  // 验证结果被使用（如完成注册）的时间。
+	CreatedAt   Time   `json:"created_at" gorm:"type:timestamp"`                    // 发送时间。
+}
@@ -0,0 +1,18 @@
+package model
+
+// model/word.go
+
+// 命中敏感词时的处理方式，严重程度依次递增。
+const (
+	WordMask   = "mask"   // 将敏感词替换为星号
+	WordReview = "review" // 允许发布，但提交给版主审核
+	WordReject = "reject" // 拒绝发布
+)
+
+// SensitiveWord 定义了敏感词及命中后的处理方式。
+type SensitiveWord struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	Word      string `json:"word" gorm:"type:varchar(50);not null;unique"` // 敏感词，匹配时忽略大小写。
+	Action    string `json:"action" gorm:"type:varchar(10);not null"`      // 命中后的处理方式。
+	CreatedAt Time   `json:"created_at" gorm:"type:timestamp"`             // 添加时间。
+}
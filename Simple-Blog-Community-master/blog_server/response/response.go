@@ -2,7 +2,10 @@ package response
 
 import (
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func Response(c *gin.Context, httpStatus int, code int, data gin.H, msg string) {
@@ -18,3 +21,13 @@ func Success(c *gin.Context, data gin.H, msg string) {
 func Fail(c *gin.Context, data gin.H, msg string) {
 	Response(c, http.StatusOK, 400, data, msg)
 }
+
+// TooManyRequests 请求过于频繁，通过 Retry-After 响应头告知需要等待的秒数
+func TooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	Response(c, http.StatusTooManyRequests, 429, nil, "请求过于频繁，请"+strconv.Itoa(seconds)+"秒后再试")
+}
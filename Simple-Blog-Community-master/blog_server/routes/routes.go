@@ -3,27 +3,117 @@ package routes
 import (
 	"blog_server/controller"
 	"blog_server/middleware"
+	"blog_server/model"
 	"github.com/gin-gonic/gin"
 )
 
 func CollectRoutes(r *gin.Engine) *gin.Engine {
 	// 允许跨域访问
 	r.Use(middleware.CORSMiddleware())
-	// 注册
-	r.POST("/register", controller.Register)
-	// 登录
-	r.POST("/login", controller.Login)
+	// 登录会话与个人访问令牌，token 的校验依赖这两张表，需要最先迁移
+	sessionController := controller.NewSessionController()
+	tokenController := controller.NewTokenController()
+	// 短信验证码，同时按 IP 和手机号限流，避免分散到多个 IP 猜测同一手机号的验证码
+	verificationController := controller.NewVerificationController()
+	smsRoutes := r.Group("/sms")
+	smsRoutes.Use(middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "sms", PerMinute: 10, Burst: 10, Key: middleware.ByIP},
+		middleware.RateLimitRule{Name: "sms", PerMinute: 5, Burst: 5, Key: middleware.ByField("phone_number")},
+	))
+	smsRoutes.POST("code", verificationController.Send)     // 发送验证码
+	smsRoutes.POST("verify", verificationController.Verify) // 校验验证码
+	// 注册，每个 IP 每分钟最多注册 5 次
+	r.POST("/register", middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "register", PerMinute: 5, Burst: 5, Key: middleware.ByIP},
+	), controller.Register)
+	// 登录，同时按 IP 和登录账号限流
+	r.POST("/login", middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "login", PerMinute: 20, Burst: 20, Key: middleware.ByIP},
+		middleware.RateLimitRule{Name: "login", PerMinute: 10, Burst: 10, Key: middleware.ByField("PhoneNumber")},
+		middleware.RateLimitRule{Name: "login", PerMinute: 10, Burst: 10, Key: middleware.ByField("Email")},
+	), controller.Login)
+	// 开启两步验证的用户登录时提交动态验证码
+	twoFactorController := controller.NewTwoFactorController()
+	r.POST("/login/2fa", middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "login_2fa", PerMinute: 10, Burst: 10, Key: middleware.ByIP},
+	), twoFactorController.Login)
+	// 忘记密码时使用验证码重置密码，同时按 IP 和手机号限流
+	r.POST("/password/reset", middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "reset", PerMinute: 10, Burst: 10, Key: middleware.ByIP},
+		middleware.RateLimitRule{Name: "reset", PerMinute: 5, Burst: 5, Key: middleware.ByField("phone_number")},
+	), controller.ResetPassword)
+	// 邮箱验证与通过邮件重置密码
+	emailController := controller.NewEmailController()
+	emailLimit := middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "email", PerMinute: 5, Burst: 5, Key: middleware.ByIP},
+		middleware.RateLimitRule{Name: "email", PerMinute: 2, Burst: 3, Key: middleware.ByField("email")},
+	)
+	r.GET("/email/verify", emailController.Verify)
+	r.POST("/password/email", emailLimit, emailController.ForgotPassword)
+	r.POST("/password/reset/email", emailLimit, emailController.ResetPassword)
+	// 外部登录服务
+	oauthController := controller.NewOAuthController()
+	r.GET("/oauth/providers", oauthController.Providers)
+	r.GET("/oauth/:provider/login", oauthController.Start)
+	r.GET("/oauth/:provider/callback", oauthController.Callback)
 	// 上传图像
 	r.POST("/upload", controller.Upload)
 	r.POST("/upload/rich_editor_upload", controller.RichEditorUpload)
 	// 用户信息管理
+	privacyController := controller.NewPrivacyController()
+	accountController := controller.NewAccountController()
+	importController := controller.NewImportController()
 	userRoutes := r.Group("/user")
 	userRoutes.Use(middleware.AuthMiddleware())
 	userRoutes.GET("", controller.GetInfo)                         // 验证用户
 	userRoutes.GET("briefInfo/:id", controller.GetBriefInfo)       // 获取用户简要信息
 	userRoutes.GET("detailedInfo/:id", controller.GetDetailedInfo) // 获取用户详细信息
-	userRoutes.PUT("avatar/:id", controller.ModifyAvatar)          // 修改头像
-	userRoutes.PUT("name/:id", controller.ModifyName)              // 修改用户名
+	userRoutes.GET("handle/:handle", controller.GetProfile)        // 根据主页标识查询用户资料
+	userRoutes.PUT("profile", controller.UpdateProfile)            // 修改个人资料
+	userRoutes.GET("privacy", privacyController.Get)               // 查询隐私设置
+	userRoutes.PUT("privacy", privacyController.Update)            // 修改隐私设置
+	userRoutes.GET("bookmarks/:id", privacyController.Bookmarks)   // 查询用户的收藏列表
+	userRoutes.GET("following/:id", privacyController.Following)   // 查询用户的关注列表
+	userRoutes.GET("followers/:id", privacyController.Followers)   // 查询用户的粉丝列表
+	userRoutes.PUT("password", controller.ChangePassword)          // 修改密码
+	userRoutes.PUT("email", emailController.Update)                // 绑定或修改邮箱
+	userRoutes.POST("email/resend", middleware.RateLimitMiddleware(
+		middleware.RateLimitRule{Name: "email_resend", PerMinute: 1, Burst: 3, Key: middleware.ByUser},
+	), emailController.Resend) // 重新发送验证邮件
+	userRoutes.GET("2fa", twoFactorController.Status)                   // 两步验证状态
+	userRoutes.POST("2fa/enroll", twoFactorController.Enroll)           // 生成两步验证密钥
+	userRoutes.POST("2fa/confirm", twoFactorController.Confirm)         // 确认并开启两步验证
+	userRoutes.DELETE("2fa", twoFactorController.Disable)               // 关闭两步验证
+	userRoutes.POST("2fa/recovery", twoFactorController.RecoveryCodes)  // 重新生成恢复码
+	userRoutes.GET("oauth", oauthController.Identities)                 // 已绑定的外部登录账号
+	userRoutes.POST("oauth/:provider/link", oauthController.Link)       // 绑定外部登录账号
+	userRoutes.POST("oauth/:provider/confirm", oauthController.Confirm) // 确认绑定外部登录账号
+	userRoutes.DELETE("oauth/:provider", oauthController.Unlink)        // 解绑外部登录账号
+	userRoutes.GET("sessions", sessionController.List)                  // 查询登录设备
+	userRoutes.DELETE("sessions/:id", sessionController.Revoke)         // 注销指定设备
+	userRoutes.DELETE("sessions", sessionController.RevokeOthers)       // 注销其他设备
+	userRoutes.POST("logout", sessionController.Logout)                 // 退出登录
+	userRoutes.GET("tokens", tokenController.List)                      // 查询个人访问令牌
+	userRoutes.POST("tokens", tokenController.Create)                   // 创建个人访问令牌
+	userRoutes.DELETE("tokens/:id", tokenController.Revoke)             // 撤销个人访问令牌
+	userRoutes.GET("deletion", accountController.Deletion)              // 查询注销申请
+	userRoutes.POST("deletion", accountController.RequestDeletion)      // 申请注销账号
+	userRoutes.DELETE("deletion", accountController.CancelDeletion)     // 撤销注销申请
+	userRoutes.GET("exports", accountController.Exports)                // 查询数据导出
+	userRoutes.POST("exports", accountController.CreateExport)          // 申请导出个人数据
+	userRoutes.GET("exports/:id/download", accountController.Download)  // 下载导出的数据
+	userRoutes.GET("imports", importController.List)                    // 查询导入任务
+	userRoutes.GET("imports/:id", importController.Show)                // 查询导入结果
+	userRoutes.POST("imports", importController.Create)                 // 上传文件批量导入文章
+	// 公开的用户主页，未登录也可以访问，登录用户按其身份应用隐私设置
+	profileRoutes := r.Group("/profiles")
+	profileRoutes.Use(middleware.OptionalAuthMiddleware())
+	profileRoutes.GET("handle/:handle", controller.PublicProfile)   // 根据主页标识查询用户资料
+	profileRoutes.GET(":id", controller.PublicBriefInfo)            // 用户简要信息
+	profileRoutes.GET(":id/detail", controller.PublicDetailedInfo)  // 用户详细信息
+	profileRoutes.GET(":id/bookmarks", privacyController.Bookmarks) // 用户的收藏列表
+	profileRoutes.GET(":id/following", privacyController.Following) // 用户的关注列表
+	profileRoutes.GET(":id/followers", privacyController.Followers) // 用户的粉丝列表
 	// 我的收藏
 	colRoutes := r.Group("/collects")
 	colRoutes.Use(middleware.AuthMiddleware())
@@ -36,6 +126,74 @@ func CollectRoutes(r *gin.Engine) *gin.Engine {
 	folRoutes.GET(":id", controller.Following)      // 查询关注
 	folRoutes.PUT("new/:id", controller.NewFollow)  // 关注
 	folRoutes.DELETE(":index", controller.UnFollow) // 取消关注
+	// 我的点赞
+	likeRoutes := r.Group("/likes")
+	likeRoutes.Use(middleware.AuthMiddleware())
+	likeController := controller.NewLikeController()
+	likeRoutes.GET(":id", likeController.Liked)     // 查询是否已点赞
+	likeRoutes.PUT("new/:id", likeController.Like)  // 点赞
+	likeRoutes.DELETE(":id", likeController.UnLike) // 取消点赞
+	likeRoutes.GET("user/:id", likeController.List) // 查询用户点赞的文章
+	// 站内通知
+	notifyRoutes := r.Group("/notifications")
+	notifyRoutes.Use(middleware.AuthMiddleware())
+	notifyController := controller.NewNotificationController()
+	notifyRoutes.GET("", notifyController.List)                         // 查询通知
+	notifyRoutes.GET("unread", notifyController.Unread)                 // 查询未读数
+	notifyRoutes.PUT("read/:id", notifyController.Read)                 // 标记单条已读
+	notifyRoutes.PUT("read", notifyController.ReadAll)                  // 全部标记已读
+	notifyRoutes.GET("preferences", notifyController.Preferences)       // 查询通知偏好
+	notifyRoutes.PUT("preferences", notifyController.UpdatePreferences) // 修改通知偏好
+	// 私信
+	msgRoutes := r.Group("/messages")
+	msgRoutes.Use(middleware.AuthMiddleware())
+	msgController := controller.NewMessageController()
+	msgRoutes.GET("", msgController.Conversations) // 查询会话列表
+	msgRoutes.GET(":id", msgController.Messages)   // 查询与指定用户的私信
+	msgRoutes.POST(":id", msgController.Send)      // 发送私信
+	msgRoutes.PUT("read/:id", msgController.Read)  // 标记已读
+	// 屏蔽用户
+	blockRoutes := r.Group("/blocks")
+	blockRoutes.Use(middleware.AuthMiddleware())
+	blockController := controller.NewBlockController()
+	blockRoutes.GET("", blockController.List)          // 查询屏蔽列表
+	blockRoutes.PUT("new/:id", blockController.Block)  // 屏蔽
+	blockRoutes.DELETE(":id", blockController.UnBlock) // 取消屏蔽
+	// 静音用户
+	muteRoutes := r.Group("/mutes")
+	muteRoutes.Use(middleware.AuthMiddleware())
+	muteController := controller.NewMuteController()
+	muteRoutes.GET("", muteController.List)         // 查询静音列表
+	muteRoutes.PUT("new/:id", muteController.Mute)  // 静音
+	muteRoutes.DELETE(":id", muteController.UnMute) // 取消静音
+	// 举报与审核
+	reportController := controller.NewReportController()
+	r.POST("/reports", middleware.AuthMiddleware(), reportController.Create) // 提交举报
+	adminRoutes := r.Group("/admin")
+	adminRoutes.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware(model.RoleModerator, model.RoleAdmin))
+	adminRoutes.GET("reports", reportController.List)               // 查询举报队列
+	adminRoutes.POST("reports/:id/handle", reportController.Handle) // 处理举报
+	// 敏感词管理，仅管理员可用
+	wordController := controller.NewWordController()
+	wordRoutes := adminRoutes.Group("words", middleware.RoleMiddleware(model.RoleAdmin))
+	wordRoutes.GET("", wordController.List)          // 查询敏感词
+	wordRoutes.POST("", wordController.Create)       // 添加敏感词
+	wordRoutes.DELETE(":id", wordController.Delete)  // 删除敏感词
+	wordRoutes.POST("reload", wordController.Reload) // 重新加载敏感词库
+	// 用户封禁管理，仅管理员可用
+	adminUserController := controller.NewAdminUserController()
+	adminUserRoutes := adminRoutes.Group("users", middleware.RoleMiddleware(model.RoleAdmin))
+	adminUserRoutes.GET("suspended", adminUserController.Suspended)      // 查询被封禁的用户
+	adminUserRoutes.POST(":id/suspend", adminUserController.Suspend)     // 封禁用户
+	adminUserRoutes.DELETE(":id/suspend", adminUserController.Reinstate) // 解除封禁
+	// 审计日志，仅管理员可用
+	auditController := controller.NewAuditController()
+	auditRoutes := adminRoutes.Group("audit", middleware.RoleMiddleware(model.RoleAdmin))
+	auditRoutes.GET("", auditController.List)         // 查询审计日志
+	auditRoutes.GET("export", auditController.Export) // 导出审计日志
+	// 实时推送
+	r.POST("/events/ticket", middleware.AuthMiddleware(), controller.EventTicket) // 签发建立推送连接的一次性票据
+	r.GET("/events", middleware.StreamTicketMiddleware(), middleware.AuthMiddleware(), controller.Events)
 	// 查询分类
 	r.GET("/category", controller.SearchCategory)         // 查询分类
 	r.GET("/category/:id", controller.SearchCategoryName) // 查询分类名
@@ -43,11 +201,13 @@ func CollectRoutes(r *gin.Engine) *gin.Engine {
 	articleRoutes := r.Group("/article")
 	//articleRoutes.Use(middleware.AuthMiddleware())
 	articleController := controller.NewArticleController()
-	articleRoutes.POST("", middleware.AuthMiddleware(), articleController.Create)      // 发布文章
-	articleRoutes.PUT(":id", middleware.AuthMiddleware(), articleController.Update)    // 修改文章
-	articleRoutes.DELETE(":id", middleware.AuthMiddleware(), articleController.Delete) // 删除文章
-	articleRoutes.GET(":id", articleController.Show)                                   // 查看文章
-	articleRoutes.POST("list", articleController.List)
+	articleRoutes.POST("", middleware.AuthMiddleware(), articleController.Create)         // 发布文章
+	articleRoutes.PUT(":id", middleware.AuthMiddleware(), articleController.Update)       // 修改文章
+	articleRoutes.DELETE(":id", middleware.AuthMiddleware(), articleController.Delete)    // 删除文章
+	articleRoutes.GET(":id", middleware.OptionalAuthMiddleware(), articleController.Show) // 查看文章
+	articleRoutes.POST("list", middleware.OptionalAuthMiddleware(), articleController.List)
+	articleRoutes.GET("rank/:kind", middleware.OptionalAuthMiddleware(), articleController.Rank)     // 文章排行榜
+	articleRoutes.GET("related/:id", middleware.OptionalAuthMiddleware(), articleController.Related) // 相关文章
 
 	return r
 }
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"blog_server/model"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// service/account.go
+
+// DeletionCoolingOff 是申请注销后的冷静期，冷静期内可以撤销注销申请，期满后账号被注销。
+const DeletionCoolingOff = 14 * 24 * time.Hour
+
+// closeInterval 是检查冷静期是否结束的间隔。
+const closeInterval = 10 * time.Minute
+
+var (
+	// errDeletionCancelled 在注销过程中用户撤销了注销申请时返回，此时不做任何修改。
+	errDeletionCancelled = errors.New("deletion cancelled")
+	// errImportRunning 在用户有正在进行的导入任务时返回，下次检查时重试。
+	errImportRunning = errors.New("导入任务正在进行")
+)
+
+// importedImagePattern 匹配文章内容中批量导入时保存的图片。
+var importedImagePattern = regexp.MustCompile(`/images/(import_[0-9a-f-]+\.[a-z]+)`)
+
+// ScheduleDeletion 为用户申请注销账号，冷静期结束后按 mode 处理其文章并注销账号，返回注销的时间。
+func ScheduleDeletion(db *gorm.DB, user model.User, mode string) (time.Time, error) {
+	at := time.Now().Add(DeletionCoolingOff)
+	err := db.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"deletion_scheduled_at": at,
+		"deletion_mode":         mode,
+	}).Error
+	return at, err
+}
+
+// CancelDeletion 撤销用户的注销申请。
+func CancelDeletion(db *gorm.DB, user model.User) error {
+	return db.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"deletion_scheduled_at": nil,
+		"deletion_mode":         "",
+	}).Error
+}
+
+// InitAccountCloser 启动后台定期检查，注销冷静期已结束的账号。
+func InitAccountCloser(db *gorm.DB) {
+	go func() {
+		for {
+			if err := closeDueAccounts(db); err != nil {
+				log.Println("close accounts failed:", err)
+			}
+			time.Sleep(closeInterval)
+		}
+	}()
+}
+
+// closeDueAccounts 注销所有冷静期已结束的账号。单个账号注销失败时记录原因并继续处理其他账号，下次检查时重试。
+func closeDueAccounts(db *gorm.DB) error {
+	var users []model.User
+	if err := db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ? AND closed_at IS NULL", time.Now()).
+		Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := CloseAccount(db, user); err != nil && err != errDeletionCancelled {
+			log.Printf("close account %d failed: %v", user.ID, err)
+			db.Model(&model.User{}).Where("id = ?", user.ID).UpdateColumn("deletion_error", truncate(err.Error(), 255))
+		}
+	}
+	return nil
+}
+
+// CloseAccount 注销账号：删除用户的点赞、关注、收藏、屏蔽、通知、登录凭证、导出文件和导入记录，
+// 按注销方式删除文章或保留文章，并将用户资料匿名化。用户记录本身保留，使其文章和私信仍能显示为已注销用户。
+// 删除文章时一并删除文章中批量导入的图片；保留文章时图片也随文章保留。
+// 注销过程中用户撤销了注销申请时回滚并返回 errDeletionCancelled。
+func CloseAccount(db *gorm.DB, user model.User) error {
+	id := strconv.Itoa(int(user.ID))
+	followers := FollowerIds(db, user.ID)
+	var exports []model.DataExport
+	db.Where("user_id = ?", user.ID).Find(&exports)
+	var imports []model.ImportJob
+	db.Where("user_id = ?", user.ID).Find(&imports)
+	var images []string
+	if user.DeletionMode == model.DeletionRemove {
+		var contents []string
+		db.Model(&model.Article{}).Where("user_id = ?", user.ID).Pluck("content", &contents)
+		for _, content := range contents {
+			for _, match := range importedImagePattern.FindAllStringSubmatch(content, -1) {
+				images = append(images, match[1])
+			}
+		}
+	}
+
+	tx := db.Begin()
+	steps := []func(tx *gorm.DB) error{
+		// 正在导入的文章会在注销后继续写入，等待导入完成后再注销
+		func(tx *gorm.DB) error {
+			var running int
+			tx.Model(&model.ImportJob{}).Where("user_id = ? AND status = ?", user.ID, model.ImportRunning).Count(&running)
+			if running > 0 {
+				return errImportRunning
+			}
+			return nil
+		},
+		// 撤销点赞，并减少被点赞文章的点赞数
+		func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE articles SET like_count = like_count - 1 WHERE like_count > 0 AND id IN "+
+				"(SELECT article_id FROM likes WHERE user_id = ?)", user.ID).Error
+		},
+		func(tx *gorm.DB) error { return tx.Where("user_id = ?", user.ID).Delete(model.Like{}).Error },
+		// 选择删除文章时，连同文章的点赞和相关文章推荐一起删除
+		func(tx *gorm.DB) error {
+			if user.DeletionMode != model.DeletionRemove {
+				return nil
+			}
+			articles := tx.Table("articles").Select("id").Where("user_id = ?", user.ID).SubQuery()
+			if err := tx.Where("article_id IN ?", articles).Delete(model.Like{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("article_id IN ? OR related_id IN ?", articles, articles).Delete(model.RelatedArticle{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("user_id = ?", user.ID).Delete(model.Article{}).Error
+		},
+		// 减少被关注用户的粉丝数
+		func(tx *gorm.DB) error {
+			for _, following := range user.Following {
+				if following == "" {
+					continue
+				}
+				if err := tx.Model(&model.User{}).Where("id = ? AND fans > 0", following).
+					UpdateColumn("fans", gorm.Expr("fans - ?", 1)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// 从粉丝的关注列表中移除该用户
+		func(tx *gorm.DB) error {
+			for _, followerId := range followers {
+				var follower model.User
+				if tx.Select("id, following").Where("id = ?", followerId).First(&follower).RecordNotFound() {
+					continue
+				}
+				following := model.Array{}
+				for _, f := range follower.Following {
+					if f != id {
+						following = append(following, f)
+					}
+				}
+				if err := tx.Model(&follower).UpdateColumn("following", following).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ? OR blocked_id = ?", user.ID, user.ID).Delete(model.Block{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ? OR muted_id = ?", user.ID, user.ID).Delete(model.Mute{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ? OR actor_id = ?", user.ID, user.ID).Delete(model.Notification{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ?", user.ID).Delete(model.NotificationPreference{}).Error
+		},
+		func(tx *gorm.DB) error { return tx.Where("user_id = ?", user.ID).Delete(model.Session{}).Error },
+		func(tx *gorm.DB) error { return tx.Where("user_id = ?", user.ID).Delete(model.AccessToken{}).Error },
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ?", user.ID).Delete(model.ExternalIdentity{}).Error
+		},
+		func(tx *gorm.DB) error { return tx.Where("user_id = ?", user.ID).Delete(model.RecoveryCode{}).Error },
+		func(tx *gorm.DB) error { return tx.Where("user_id = ?", user.ID).Delete(model.DataExport{}).Error },
+		func(tx *gorm.DB) error {
+			jobs := tx.Table("import_jobs").Select("id").Where("user_id = ?", user.ID).SubQuery()
+			if err := tx.Where("job_id IN ?", jobs).Delete(model.ImportItem{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("user_id = ?", user.ID).Delete(model.ImportJob{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("phone_number = ?", user.PhoneNumber).Delete(model.VerificationCode{}).Error
+		},
+		// 匿名化用户资料，手机号、邮箱和主页标识释放后可以被重新注册。
+		// 只有注销申请仍然有效时才更新，期间撤销了申请则回滚之前的所有步骤
+		func(tx *gorm.DB) error {
+			result := tx.Model(&model.User{}).
+				Where("id = ? AND deletion_scheduled_at IS NOT NULL AND closed_at IS NULL", user.ID).Updates(map[string]interface{}{
+				"user_name":             model.ClosedUserName,
+				"phone_number":          "deleted_" + id,
+				"phone_verified":        false,
+				"email":                 nil,
+				"email_verified":        false,
+				"password":              "",
+				"avatar":                "/images/default_avatar.png",
+				"handle":                nil,
+				"bio":                   "",
+				"location":              "",
+				"website":               "",
+				"social_links":          model.Links{},
+				"cover_image":           "",
+				"collects":              model.Array{},
+				"following":             model.Array{},
+				"fans":                  0,
+				"token_version":         gorm.Expr("token_version + ?", 1),
+				"totp_secret":           "",
+				"totp_enabled":          false,
+				"profile_visibility":    model.VisibilityPrivate,
+				"bookmarks_visibility":  model.VisibilityPrivate,
+				"following_visibility":  model.VisibilityPrivate,
+				"followers_visibility":  model.VisibilityPrivate,
+				"deletion_scheduled_at": nil,
+				"deletion_error":        "",
+				"closed_at":             time.Now(),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errDeletionCancelled
+			}
+			return nil
+		},
+	}
+	for _, step := range steps {
+		if err := step(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	hub.CloseUser(user.ID, "")
+	for _, export := range exports {
+		if export.File != "" {
+			os.Remove(export.File)
+		}
+	}
+	// 已完成的导入任务已经删除了上传的文件，这里清理尚未开始的任务
+	for _, job := range imports {
+		if job.File != "" {
+			os.Remove(job.File)
+		}
+	}
+	for _, image := range images {
+		os.Remove(path.Join(imageDir, image))
+	}
+	entry := model.AuditLog{
+		ActorId:    user.ID,
+		Action:     model.AuditAccountClose,
+		TargetType: "user",
+		TargetId:   id,
+		// 账号已注销，审计日志中不保留昵称、手机号、邮箱等个人信息
+		Before: snapshot(map[string]interface{}{"id": user.ID, "role": user.Role}),
+		After:  snapshot(map[string]string{"mode": user.DeletionMode}),
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Println("write audit log failed:", err)
+	}
+	return nil
+}
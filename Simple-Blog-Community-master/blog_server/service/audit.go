@@ -0,0 +1,87 @@
+package service
+
+import (
+	"blog_server/model"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"log"
+	"strconv"
+	"time"
+)
+
+// service/audit.go
+
+// SuspendedAuditWindow 是被封禁用户重复尝试登录或访问时，同一用户记录审计日志的最小间隔。
+const SuspendedAuditWindow = 10 * time.Minute
+
+// Audit 记录一条审计日志，before 和 after 为操作前后的快照，会被序列化为 JSON，为 nil 时不记录。
+// 审计日志写入失败不影响业务处理，只输出日志。
+func Audit(db *gorm.DB, c *gin.Context, actorId uint, action, targetType, targetId string, before, after interface{}) {
+	entry := model.AuditLog{
+		ActorId:    actorId,
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Println("write audit log failed:", err)
+	}
+}
+
+// AuditThrottled 与 Audit 相同，但同一操作者的同一操作在 window 内只记录一次，
+// 用于被封禁用户反复重试这类可能被大量触发的操作，避免审计日志被刷满。
+func AuditThrottled(db *gorm.DB, c *gin.Context, window time.Duration, actorId uint, action, targetType, targetId string, before, after interface{}) {
+	key := "audit:" + action + ":" + strconv.Itoa(int(actorId))
+	if ok, _ := TakeToken(key, 1/window.Seconds(), 1); !ok {
+		return
+	}
+	Audit(db, c, actorId, action, targetType, targetId, before, after)
+}
+
+// UserSnapshot 返回用于审计日志的用户快照，不包含密码等敏感字段。
+func UserSnapshot(user model.User) gin.H {
+	snapshot := gin.H{
+		"id":             user.ID,
+		"name":           user.UserName,
+		"phone_number":   user.PhoneNumber,
+		"avatar":         user.Avatar,
+		"role":           user.Role,
+		"suspend_reason": user.SuspendReason,
+		"content_hidden": user.ContentHidden,
+	}
+	if user.Email != nil {
+		snapshot["email"] = *user.Email
+	}
+	if user.SuspendedUntil != nil {
+		snapshot["suspended_until"] = *user.SuspendedUntil
+	}
+	return snapshot
+}
+
+// snapshot 将快照序列化为 JSON 字符串。
+func snapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// truncate 将字符串截断为最多 n 个字节，并保证不截断多字节字符。
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && (s[n]&0xC0) == 0x80 {
+		n--
+	}
+	return s[:n]
+}
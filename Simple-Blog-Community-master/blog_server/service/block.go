@@ -0,0 +1,35 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+)
+
+// service/block.go
+
+// IsBlocked 判断 userId 是否屏蔽了 blockedId。
+func IsBlocked(db *gorm.DB, userId, blockedId uint) bool {
+	var count int
+	db.Model(model.Block{}).Where("user_id = ? AND blocked_id = ?", userId, blockedId).Count(&count)
+	return count > 0
+}
+
+// HiddenAuthorIds 返回用户屏蔽或静音的所有用户 ID，这些用户的文章不会出现在该用户的文章列表中。
+func HiddenAuthorIds(db *gorm.DB, userId uint) []uint {
+	var blocked, muted []uint
+	db.Model(model.Block{}).Where("user_id = ?", userId).Pluck("blocked_id", &blocked)
+	db.Model(model.Mute{}).Where("user_id = ?", userId).Pluck("muted_id", &muted)
+	return append(blocked, muted...)
+}
+
+// HidingUserIds 返回屏蔽或静音了指定用户的所有用户 ID，即不应看到该用户文章的用户。
+func HidingUserIds(db *gorm.DB, authorId uint) map[uint]bool {
+	var blockers, muters []uint
+	db.Model(model.Block{}).Where("blocked_id = ?", authorId).Pluck("user_id", &blockers)
+	db.Model(model.Mute{}).Where("muted_id = ?", authorId).Pluck("user_id", &muters)
+	ids := make(map[uint]bool, len(blockers)+len(muters))
+	for _, id := range append(blockers, muters...) {
+		ids[id] = true
+	}
+	return ids
+}
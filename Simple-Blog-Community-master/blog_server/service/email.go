@@ -0,0 +1,42 @@
+package service
+
+import (
+	"blog_server/model"
+	"errors"
+	"github.com/jinzhu/gorm"
+)
+
+// service/email.go
+
+// ErrEmailTaken 表示邮箱已被其他用户验证。
+var ErrEmailTaken = errors.New("邮箱已被使用")
+
+// EmailTaken 判断邮箱是否已被其他用户验证。未验证的邮箱不算被占用，
+// 避免他人抢先绑定却不验证，使真正的所有者无法绑定自己的邮箱。
+func EmailTaken(db *gorm.DB, email string, userId uint) bool {
+	return !db.Where("email = ? AND email_verified = ? AND id <> ?", email, true, userId).First(&model.User{}).RecordNotFound()
+}
+
+// MarkEmailVerified 将用户的邮箱标记为已验证，并移除其他用户绑定但未验证的同一邮箱。
+// 其他用户已经验证过该邮箱时返回 ErrEmailTaken。
+func MarkEmailVerified(db *gorm.DB, userId uint, email string) error {
+	tx := db.Begin()
+	// 在同一条语句中检查其他用户是否已验证该邮箱，避免两个用户同时验证时都成功
+	result := tx.Exec("UPDATE users SET email_verified = ? WHERE id = ? AND email = ? AND NOT EXISTS "+
+		"(SELECT 1 FROM (SELECT id FROM users WHERE email = ? AND email_verified = ? AND id <> ?) AS verified)",
+		true, userId, email, email, true, userId)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrEmailTaken
+	}
+	if err := tx.Model(&model.User{}).Where("email = ? AND email_verified = ? AND id <> ?", email, false, userId).
+		UpdateColumn("email", nil).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"archive/zip"
+	"blog_server/model"
+	"encoding/json"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// service/export.go
+
+// exportDir 是导出文件的保存目录，该目录不通过静态文件对外提供，只能通过下载接口获取。
+const exportDir = "./exports"
+
+// ExportTTL 是导出文件的保留时间，过期后文件被删除。
+const ExportTTL = 7 * 24 * time.Hour
+
+// exportInterval 是检查待生成与过期导出任务的间隔，新任务提交后会立即开始处理。
+const exportInterval = time.Minute
+
+// exportQueue 用于通知后台任务有新的导出申请。
+var exportQueue = make(chan struct{}, 1)
+
+// QueueExport 通知后台任务尽快处理新的导出申请。
+func QueueExport() {
+	select {
+	case exportQueue <- struct{}{}:
+	default:
+	}
+}
+
+// InitExporter 启动后台任务，依次生成待处理的数据导出，并删除过期的导出文件。
+// 服务重启前未完成的任务会重新生成。
+func InitExporter(db *gorm.DB) {
+	db.Model(&model.DataExport{}).Where("status = ?", model.ExportRunning).UpdateColumn("status", model.ExportPending)
+	go func() {
+		ticker := time.NewTicker(exportInterval)
+		defer ticker.Stop()
+		for {
+			if err := processExports(db); err != nil {
+				log.Println("process exports failed:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-exportQueue:
+			}
+		}
+	}()
+}
+
+// processExports 生成所有待处理的导出，并清理过期的导出文件。
+func processExports(db *gorm.DB) error {
+	var expired []model.DataExport
+	if err := db.Where("status = ? AND expires_at <= ?", model.ExportDone, time.Now()).Find(&expired).Error; err != nil {
+		return err
+	}
+	for _, export := range expired {
+		os.Remove(export.File)
+		db.Model(&export).Updates(map[string]interface{}{"status": model.ExportExpired, "file": ""})
+	}
+	var pending []model.DataExport
+	if err := db.Where("status = ?", model.ExportPending).Order("id").Find(&pending).Error; err != nil {
+		return err
+	}
+	for _, export := range pending {
+		db.Model(&export).UpdateColumn("status", model.ExportRunning)
+		file, size, err := buildExport(db, export)
+		now := time.Now()
+		if err != nil {
+			log.Println("build export failed:", err)
+			db.Model(&export).Updates(map[string]interface{}{
+				"status": model.ExportFailed, "error": "生成失败，请重新申请", "finished_at": now,
+			})
+			continue
+		}
+		db.Model(&export).Updates(map[string]interface{}{
+			"status": model.ExportDone, "file": file, "size": size, "finished_at": now, "expires_at": now.Add(ExportTTL),
+		})
+		Notify(db, export.UserId, 0, model.NotifySystem, strconv.Itoa(int(export.ID)), "你申请的个人数据导出已生成，可以在 7 天内下载")
+	}
+	return nil
+}
+
+// buildExport 将用户的个人资料、文章、收藏、点赞和关注列表打包为 ZIP 文件，返回文件路径和大小。
+// 文章以带有 front matter 的 Markdown 文件保存在 articles 目录中。
+func buildExport(db *gorm.DB, export model.DataExport) (string, int64, error) {
+	var user model.User
+	if err := db.Where("id = ?", export.UserId).First(&user).Error; err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(exportDir, 0700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(exportDir, fmt.Sprintf("%d-%d.zip", user.ID, export.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	w := zip.NewWriter(file)
+	err = writeExport(db, w, user)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+// exportArticle 是导出的收藏和点赞列表中的文章信息。
+type exportArticle struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	AuthorId uint       `json:"author_id"`
+	Time     model.Time `json:"time"`
+}
+
+// writeExport 将用户的数据写入 ZIP 文件。
+func writeExport(db *gorm.DB, w *zip.Writer, user model.User) error {
+	profile := map[string]interface{}{
+		"id":           user.ID,
+		"name":         user.UserName,
+		"phone_number": user.PhoneNumber,
+		"email":        user.Email,
+		"handle":       user.Handle,
+		"avatar":       user.Avatar,
+		"bio":          user.Bio,
+		"location":     user.Location,
+		"website":      user.Website,
+		"social_links": user.SocialLinks,
+		"cover_image":  user.CoverImage,
+		"fans":         user.Fans,
+		"created_at":   model.Time(user.CreatedAt),
+		"privacy": map[string]string{
+			"profile":   user.ProfileVisibility,
+			"bookmarks": user.BookmarksVisibility,
+			"following": user.FollowingVisibility,
+			"followers": user.FollowersVisibility,
+		},
+	}
+	if err := writeJSON(w, "profile.json", profile); err != nil {
+		return err
+	}
+	// 文章
+	var articles []model.Article
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&articles).Error; err != nil {
+		return err
+	}
+	categories := map[uint]string{}
+	var list []model.Category
+	db.Find(&list)
+	for _, category := range list {
+		categories[category.ID] = category.CategoryName
+	}
+	for _, article := range articles {
+		name := fmt.Sprintf("articles/%s-%s.md", time.Time(article.CreatedAt).Format("2006-01-02"), article.ID)
+		f, err := w.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, articleMarkdown(article, categories[article.CategoryId])); err != nil {
+			return err
+		}
+	}
+	// 收藏
+	var bookmarks []exportArticle
+	if ids := nonEmpty(user.Collects); len(ids) > 0 {
+		db.Table("articles").Select("id, title, user_id AS author_id").Where("id IN (?)", ids).Scan(&bookmarks)
+	}
+	if err := writeJSON(w, "bookmarks.json", bookmarks); err != nil {
+		return err
+	}
+	// 点赞
+	var likes []exportArticle
+	db.Table("likes").Select("articles.id, articles.title, articles.user_id AS author_id, likes.created_at AS time").
+		Joins("JOIN articles ON articles.id = likes.article_id").
+		Where("likes.user_id = ?", user.ID).Order("likes.created_at").Scan(&likes)
+	if err := writeJSON(w, "likes.json", likes); err != nil {
+		return err
+	}
+	// 关注
+	var following []model.UserInfo
+	if ids := nonEmpty(user.Following); len(ids) > 0 {
+		db.Table("users").Select("id, avatar, user_name").Where("id IN (?)", ids).Scan(&following)
+	}
+	return writeJSON(w, "following.json", following)
+}
+
+// articleMarkdown 将文章转换为带有 YAML front matter 的 Markdown。
+func articleMarkdown(article model.Article, category string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(article.Title) + "\n")
+	b.WriteString("date: " + article.CreatedAt.String() + "\n")
+	b.WriteString("updated: " + article.UpdatedAt.String() + "\n")
+	if category != "" {
+		b.WriteString("categories: [" + strconv.Quote(category) + "]\n")
+	}
+	if article.HeadImage != "" {
+		b.WriteString("cover: " + strconv.Quote(article.HeadImage) + "\n")
+	}
+	b.WriteString("id: " + article.ID.String() + "\n")
+	b.WriteString("views: " + strconv.Itoa(article.ViewCount) + "\n")
+	b.WriteString("likes: " + strconv.Itoa(article.LikeCount) + "\n")
+	b.WriteString("---\n\n")
+	b.WriteString(article.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeJSON 将 v 序列化为带缩进的 JSON 写入 ZIP 文件。
+func writeJSON(w *zip.Writer, name string, v interface{}) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// nonEmpty 去掉列表中的空字符串，数据库中的空列表会被读取为只有一个空字符串的列表。
+func nonEmpty(list model.Array) []string {
+	var result []string
+	for _, s := range list {
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// service/filter.go
+
+// severity 定义了各处理方式的严重程度，命中多个敏感词时取最严重的处理方式。
+var severity = map[string]int{"": 0, model.WordMask: 1, model.WordReview: 2, model.WordReject: 3}
+
+// FilterResult 是敏感词检查的结果。
+type FilterResult struct {
+	Action string   // 最严重的处理方式，为空表示未命中
+	Words  []string // 命中的敏感词
+	Masked string   // 将处理方式为 mask 的敏感词替换为星号后的文本
+}
+
+// Filter 是基于 Aho-Corasick 自动机的多模式敏感词匹配器。
+// 匹配时忽略大小写，并跳过字母、数字和汉字以外的字符，避免用空格或符号分隔敏感词绕过检查。
+// 纯英文和数字组成的敏感词只匹配完整的单词，例如 he 不会命中 here。
+type Filter struct {
+	nodes []acNode
+}
+
+// acNode 是自动机中的一个节点。
+type acNode struct {
+	next   map[rune]int
+	fail   int
+	word   string // 以该节点结尾的敏感词，为空表示不是敏感词的结尾
+	length int    // 敏感词的有效字符数
+	action string
+	ascii  bool // 敏感词是否只由英文字母和数字组成
+}
+
+var filter atomic.Value
+
+func init() {
+	filter.Store(NewFilter(nil))
+}
+
+// NewFilter 根据敏感词列表构建匹配器。
+func NewFilter(words []model.SensitiveWord) *Filter {
+	f := &Filter{nodes: []acNode{{next: map[rune]int{}}}}
+	for _, w := range words {
+		runes := normalize(w.Word)
+		if len(runes) == 0 {
+			continue
+		}
+		cur := 0
+		for _, r := range runes {
+			next, ok := f.nodes[cur].next[r]
+			if !ok {
+				next = len(f.nodes)
+				f.nodes = append(f.nodes, acNode{next: map[rune]int{}})
+				f.nodes[cur].next[r] = next
+			}
+			cur = next
+		}
+		node := &f.nodes[cur]
+		if severity[w.Action] > severity[node.action] {
+			node.action = w.Action
+		}
+		node.word = w.Word
+		node.length = len(runes)
+		node.ascii = true
+		for _, r := range runes {
+			if !isASCIIWordRune(r) {
+				node.ascii = false
+			}
+		}
+	}
+	// 按广度优先的顺序计算失败指针
+	queue := []int{}
+	for _, child := range f.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range f.nodes[cur].next {
+			fail := f.nodes[cur].fail
+			for fail > 0 && !hasEdge(f.nodes[fail], r) {
+				fail = f.nodes[fail].fail
+			}
+			if next, ok := f.nodes[fail].next[r]; ok && next != child {
+				f.nodes[child].fail = next
+			}
+			queue = append(queue, child)
+		}
+	}
+	return f
+}
+
+// Check 检查文本中的敏感词。
+func (f *Filter) Check(text string) FilterResult {
+	var result FilterResult
+	original := []rune(text)
+	// positions 记录参与匹配的有效字符在原文中的位置
+	var positions []int
+	for i, r := range original {
+		if isWordRune(r) {
+			positions = append(positions, i)
+		}
+	}
+	masked := make([]bool, len(original))
+	seen := map[string]bool{}
+	cur := 0
+	for i, pos := range positions {
+		r := unicode.ToLower(original[pos])
+		for cur > 0 && !hasEdge(f.nodes[cur], r) {
+			cur = f.nodes[cur].fail
+		}
+		if next, ok := f.nodes[cur].next[r]; ok {
+			cur = next
+		}
+		// 沿失败指针检查所有以当前字符结尾的敏感词
+		for n := cur; n > 0; n = f.nodes[n].fail {
+			node := f.nodes[n]
+			if node.word == "" {
+				continue
+			}
+			start, end := positions[i-node.length+1], pos
+			if node.ascii && (start > 0 && isASCIIWordRune(original[start-1]) ||
+				end+1 < len(original) && isASCIIWordRune(original[end+1])) {
+				continue
+			}
+			if !seen[node.word] {
+				seen[node.word] = true
+				result.Words = append(result.Words, node.word)
+			}
+			if severity[node.action] > severity[result.Action] {
+				result.Action = node.action
+			}
+			if node.action == model.WordMask {
+				for j := i - node.length + 1; j <= i; j++ {
+					masked[positions[j]] = true
+				}
+			}
+		}
+	}
+	var b strings.Builder
+	for i, r := range original {
+		if masked[i] {
+			b.WriteRune('*')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	result.Masked = b.String()
+	return result
+}
+
+// GetFilter 返回当前使用的敏感词匹配器。
+func GetFilter() *Filter {
+	return filter.Load().(*Filter)
+}
+
+// ReloadFilter 从数据库重新加载敏感词并替换当前的匹配器，无需重启服务。
+func ReloadFilter(db *gorm.DB) error {
+	var words []model.SensitiveWord
+	if err := db.Find(&words).Error; err != nil {
+		return err
+	}
+	filter.Store(NewFilter(words))
+	return nil
+}
+
+// FlagForReview 为命中敏感词的内容创建一条系统举报，交由版主审核。
+// 同一内容已有待处理的系统举报时（例如多次编辑文章），更新该举报中命中的敏感词，不再重复创建。
+func FlagForReview(db *gorm.DB, targetType, targetId string, targetUserId uint, words []string) error {
+	var report model.Report
+	db.Where("reporter_id = ? AND target_type = ? AND target_id = ? AND reason = ? AND status = ?",
+		0, targetType, targetId, model.ReportSensitive, model.ReportPending).First(&report)
+	if report.ID != 0 {
+		return db.Model(&report).UpdateColumn("detail", sensitiveDetail(words)).Error
+	}
+	report = model.Report{
+		TargetType:   targetType,
+		TargetId:     targetId,
+		TargetUserId: targetUserId,
+		Reason:       model.ReportSensitive,
+		Detail:       sensitiveDetail(words),
+		Status:       model.ReportPending,
+	}
+	return db.Create(&report).Error
+}
+
+// sensitiveDetail 生成系统举报的详细说明，超出字段长度时截断。
+func sensitiveDetail(words []string) string {
+	return truncate("命中敏感词："+strings.Join(words, "、"), 500)
+}
+
+// hasEdge 判断节点是否有指定字符的子节点。
+func hasEdge(node acNode, r rune) bool {
+	_, ok := node.next[r]
+	return ok
+}
+
+// normalize 将敏感词转换为小写，并去除字母、数字和汉字以外的字符。
+func normalize(word string) []rune {
+	var runes []rune
+	for _, r := range word {
+		if isWordRune(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	return runes
+}
+
+// isASCIIWordRune 判断字符是否为英文字母或数字。
+func isASCIIWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// isWordRune 判断字符是否参与敏感词匹配。
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"blog_server/model"
+	"reflect"
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	f := NewFilter([]model.SensitiveWord{
+		{Word: "he", Action: model.WordMask},
+		{Word: "she", Action: model.WordMask},
+		{Word: "hers", Action: model.WordReview},
+		{Word: "his", Action: model.WordMask},
+		{Word: "赌博", Action: model.WordReject},
+		{Word: "网络赌博", Action: model.WordReview},
+		{Word: "博彩", Action: model.WordMask},
+		{Word: "法轮", Action: model.WordMask},
+	})
+	tests := []struct {
+		name   string
+		text   string
+		action string
+		words  []string
+		masked string
+	}{
+		{"未命中", "hello world", "", nil, "hello world"},
+		{"英文只匹配完整单词", "here ushers this", "", nil, "here ushers this"},
+		{"英文完整单词", "ask he now", model.WordMask, []string{"he"}, "ask ** now"},
+		{"忽略大小写", "SHE said", model.WordMask, []string{"she"}, "*** said"},
+		{"重叠的敏感词", "hers", model.WordReview, []string{"hers"}, "hers"},
+		{"中文包含关系取最严重的处理方式", "禁止网络赌博", model.WordReject, []string{"网络赌博", "赌博"}, "禁止网络赌博"},
+		{"中文重叠的敏感词", "赌博彩票", model.WordReject, []string{"赌博", "博彩"}, "赌**票"},
+		{"跳过分隔符号", "法 轮", model.WordMask, []string{"法轮"}, "* *"},
+		{"英文单词与汉字相邻", "he说法轮", model.WordMask, []string{"he", "法轮"}, "**说**"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.Check(tt.text)
+			if got.Action != tt.action || !reflect.DeepEqual(got.Words, tt.words) || got.Masked != tt.masked {
+				t.Errorf("Check(%q) = {%q %v %q}, want {%q %v %q}",
+					tt.text, got.Action, got.Words, got.Masked, tt.action, tt.words, tt.masked)
+			}
+		})
+	}
+}
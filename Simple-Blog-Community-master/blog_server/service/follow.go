@@ -0,0 +1,37 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"strconv"
+)
+
+// service/follow.go
+
+// FollowerIds 查询关注了指定用户的所有用户 ID。
+func FollowerIds(db *gorm.DB, userId uint) []uint {
+	id := strconv.Itoa(int(userId))
+	var users []model.User
+	// 关注列表以 "|" 分隔存储，先模糊匹配再逐个确认
+	db.Select("id, following").Where("following LIKE ?", "%"+id+"%").Find(&users)
+	var ids []uint
+	for _, user := range users {
+		for _, following := range user.Following {
+			if following == id {
+				ids = append(ids, user.ID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// PublishToFollowers 向关注了指定用户的所有用户推送实时事件，屏蔽或静音了该用户的关注者除外。
+func PublishToFollowers(db *gorm.DB, userId uint, kind string, data interface{}) {
+	hiding := HidingUserIds(db, userId)
+	for _, id := range FollowerIds(db, userId) {
+		if !hiding[id] {
+			hub.Publish(id, kind, data)
+		}
+	}
+}
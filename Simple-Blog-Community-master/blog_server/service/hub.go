@@ -0,0 +1,212 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// service/hub.go
+
+const (
+	subscriberBuffer = 32          // 每个连接的事件缓冲区大小，写满时断开该连接
+	historySize      = 100         // 每个用户保留的最近事件数量，用于断线重连后补发
+	historyTTL       = time.Hour   // 事件保留的最长时间
+	sweepInterval    = time.Minute // 清除过期历史事件的间隔
+)
+
+// Event 是推送给客户端的实时事件。
+type Event struct {
+	ID        uint64      `json:"id"`   // 事件 ID，单调递增，用作 SSE 的 Last-Event-ID
+	Type      string      `json:"type"` // 事件类型，例如 notification、article
+	Data      interface{} `json:"data"` // 事件内容
+	createdAt time.Time
+}
+
+// Subscriber 表示一个实时推送连接。
+type Subscriber struct {
+	userId  uint
+	session string        // 建立连接时使用的会话 ID，使用个人访问令牌时为空
+	tokenId uint          // 建立连接时使用的个人访问令牌 ID
+	Events  chan Event    // 待推送的事件
+	Dropped chan struct{} // 连接因处理过慢被断开时关闭
+	Revoked chan struct{} // 连接使用的会话或令牌被注销时关闭
+}
+
+// Hub 是进程内的发布订阅中心，负责将事件分发给用户的所有连接，并保留最近的事件以便补发。
+type Hub struct {
+	mu      sync.Mutex
+	nextId  uint64
+	subs    map[uint]map[*Subscriber]struct{}
+	history map[uint]*eventHistory
+	swept   uint64 // 已清除的历史记录中最大的事件 ID，新建的历史记录以此作为 evicted
+}
+
+// eventHistory 保存用户最近的事件，evicted 记录已被清除的事件中最大的 ID。
+type eventHistory struct {
+	events  []Event
+	evicted uint64
+}
+
+var hub = NewHub()
+
+// NewHub 创建发布订阅中心，并定期清除过期的历史事件。
+// 事件 ID 从启动时的微秒时间戳开始递增，重启后的 ID 不会小于重启前发出的 ID；
+// 重启前的事件都视为已清除，携带旧 ID 重连的客户端会收到 resync。
+func NewHub() *Hub {
+	epoch := uint64(time.Now().UnixNano() / int64(time.Microsecond))
+	h := &Hub{
+		nextId:  epoch,
+		swept:   epoch,
+		subs:    make(map[uint]map[*Subscriber]struct{}),
+		history: make(map[uint]*eventHistory),
+	}
+	go func() {
+		for range time.Tick(sweepInterval) {
+			h.sweep()
+		}
+	}()
+	return h
+}
+
+// GetHub 返回全局的发布订阅中心。
+func GetHub() *Hub {
+	return hub
+}
+
+// Subscribe 为用户创建一个订阅，并返回 ID 大于 lastEventId 的历史事件用于补发。
+// session 和 tokenId 是建立连接时使用的会话或个人访问令牌，注销后通过 CloseSession、CloseToken 断开连接。
+// 当需要补发的事件已经不在保留范围内，或者 lastEventId 不是本进程发出的 ID 时，resync 为 true，客户端应当重新拉取数据。
+func (h *Hub) Subscribe(userId uint, session string, tokenId uint, lastEventId uint64) (sub *Subscriber, replay []Event, resync bool) {
+	sub = &Subscriber{
+		userId:  userId,
+		session: session,
+		tokenId: tokenId,
+		Events:  make(chan Event, subscriberBuffer),
+		Dropped: make(chan struct{}),
+		Revoked: make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userId] == nil {
+		h.subs[userId] = make(map[*Subscriber]struct{})
+	}
+	h.subs[userId][sub] = struct{}{}
+	if lastEventId > 0 {
+		history := h.prune(userId)
+		resync = lastEventId < history.evicted || lastEventId > h.nextId
+		for _, event := range history.events {
+			if event.ID > lastEventId {
+				replay = append(replay, event)
+			}
+		}
+	}
+	return sub, replay, resync
+}
+
+// Unsubscribe 取消订阅。
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Publish 向用户的所有连接推送事件。连接的缓冲区已满时将其断开，客户端重连后可通过 Last-Event-ID 补发。
+func (h *Hub) Publish(userId uint, kind string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextId++
+	event := Event{ID: h.nextId, Type: kind, Data: data, createdAt: time.Now()}
+	history := h.prune(userId)
+	history.events = append(history.events, event)
+	if n := len(history.events) - historySize; n > 0 {
+		history.evicted = history.events[n-1].ID
+		history.events = history.events[n:]
+	}
+	for sub := range h.subs[userId] {
+		select {
+		case sub.Events <- event:
+		default:
+			h.remove(sub)
+			close(sub.Dropped)
+		}
+	}
+}
+
+// CloseSession 断开使用指定会话建立的所有连接。
+func (h *Hub) CloseSession(session string) {
+	h.closeWhere(func(sub *Subscriber) bool { return sub.session == session })
+}
+
+// CloseToken 断开使用指定个人访问令牌建立的所有连接。
+func (h *Hub) CloseToken(tokenId uint) {
+	h.closeWhere(func(sub *Subscriber) bool { return sub.tokenId == tokenId })
+}
+
+// CloseUser 断开用户的所有连接，except 不为空时保留使用该会话建立的连接。
+func (h *Hub) CloseUser(userId uint, except string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs[userId] {
+		if except == "" || sub.session != except {
+			h.remove(sub)
+			close(sub.Revoked)
+		}
+	}
+}
+
+// closeWhere 断开所有满足条件的连接。
+func (h *Hub) closeWhere(match func(sub *Subscriber) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subs {
+		for sub := range subs {
+			if match(sub) {
+				h.remove(sub)
+				close(sub.Revoked)
+			}
+		}
+	}
+}
+
+// remove 移除订阅，调用方需持有锁。
+func (h *Hub) remove(sub *Subscriber) {
+	subs := h.subs[sub.userId]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.userId)
+	}
+}
+
+// prune 清除用户已过期的历史事件并返回用户的历史记录，调用方需持有锁。
+func (h *Hub) prune(userId uint) *eventHistory {
+	history := h.history[userId]
+	if history == nil {
+		history = &eventHistory{evicted: h.swept}
+		h.history[userId] = history
+	}
+	i := 0
+	for i < len(history.events) && time.Since(history.events[i].createdAt) > historyTTL {
+		history.evicted = history.events[i].ID
+		i++
+	}
+	history.events = history.events[i:]
+	return history
+}
+
+// sweep 清除所有用户已过期的历史事件，并删除没有事件的历史记录，避免长期不活跃的用户占用内存。
+func (h *Hub) sweep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userId := range h.history {
+		history := h.prune(userId)
+		if len(history.events) == 0 {
+			if history.evicted > h.swept {
+				h.swept = history.evicted
+			}
+			delete(h.history, userId)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"archive/zip"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// service/image.go
+
+// imageDir 是图片的保存目录，与上传接口使用同一个目录，通过 /images 对外提供。
+const imageDir = "./static/images"
+
+const (
+	maxImportImage      = 10 << 20  // 导入时单张图片允许的最大大小
+	maxImportImages     = 1000      // 一个导入任务最多保存的图片数
+	maxImportImageBytes = 300 << 20 // 一个导入任务最多保存的图片总大小
+)
+
+var (
+	errImageNotFound = errors.New("导入文件中没有该图片")
+	errImageType     = errors.New("不是支持的图片格式")
+	errPrivateHost   = errors.New("不允许访问内网地址")
+	errImageQuota    = errors.New("本次导入的图片数量或总大小已达上限")
+	htmlImage        = regexp.MustCompile(`(<img\b[^>]*?\bsrc\s*=\s*["'])([^"']+)(["'])`)
+	// imageTypes 是导入时允许保存的图片类型及其扩展名，不包括可能包含脚本的 SVG。
+	imageTypes = map[string]string{
+		"image/png":  ".png",
+		"image/jpeg": ".jpg",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+		"image/bmp":  ".bmp",
+	}
+)
+
+// imageClient 用于下载导入文章中的远程图片，拒绝连接内网地址，避免借助导入访问服务器所在的内部网络。
+var imageClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, c syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+					ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+					return errPrivateHost
+				}
+				return nil
+			},
+		}).DialContext,
+	},
+}
+
+// imageUploader 将导入文章中的图片保存到本站，图片来自 ZIP 压缩包中的文件或远程地址。
+// 同一次导入中相同的图片只保存一次，保存的图片数量和总大小超过上限后不再下载或保存。
+type imageUploader struct {
+	files map[string]*zip.File
+	saved map[string]string
+	count int   // 已保存的图片数
+	bytes int64 // 已保存的图片总大小
+}
+
+// newImageUploader 创建图片上传器，files 为导入的 ZIP 压缩包中的文件，可以为空。
+func newImageUploader(files []*zip.File) *imageUploader {
+	u := &imageUploader{files: map[string]*zip.File{}, saved: map[string]string{}}
+	for _, f := range files {
+		u.files[strings.TrimPrefix(path.Clean("/"+f.Name), "/")] = f
+	}
+	return u
+}
+
+// rewrite 上传正文中 <img> 标签引用的图片并替换为本站地址，返回替换后的正文和上传失败的提示。
+// 上传失败的图片保留原地址。
+func (u *imageUploader) rewrite(content, base string) (string, []string) {
+	var warnings []string
+	content = htmlImage.ReplaceAllStringFunc(content, func(tag string) string {
+		m := htmlImage.FindStringSubmatch(tag)
+		uploaded, err := u.upload(m[2], base)
+		if err != nil {
+			warnings = append(warnings, "图片 "+m[2]+" 上传失败："+err.Error())
+			return tag
+		}
+		return m[1] + uploaded + m[3]
+	})
+	return content, warnings
+}
+
+// upload 保存一张图片并返回本站地址。src 为远程地址时下载图片，否则在 ZIP 压缩包中查找，
+// 依次尝试相对于文章所在目录的路径、相对于压缩包根目录的路径，以及 Hexo 的 source 目录。
+func (u *imageUploader) upload(src, base string) (string, error) {
+	src = strings.TrimSpace(src)
+	if strings.HasPrefix(src, "data:") {
+		return src, nil
+	}
+	key := base + "|" + src
+	if saved, ok := u.saved[key]; ok {
+		return saved, nil
+	}
+	if u.count >= maxImportImages || u.bytes >= maxImportImageBytes {
+		return "", errImageQuota
+	}
+	var data []byte
+	var err error
+	if parsed, parseErr := url.Parse(src); parseErr == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") {
+		data, err = downloadImage(src)
+	} else if parseErr == nil && parsed.Scheme == "" && parsed.Host == "" {
+		data, err = u.readFile(parsed.Path, base)
+	} else {
+		err = errImageType
+	}
+	if err != nil {
+		return "", err
+	}
+	if u.bytes+int64(len(data)) > maxImportImageBytes {
+		return "", errImageQuota
+	}
+	ext, ok := imageTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", errImageType
+	}
+	name := "import_" + uuid.NewV4().String() + ext
+	if err := ioutil.WriteFile(path.Join(imageDir, name), data, 0644); err != nil {
+		return "", err
+	}
+	u.count++
+	u.bytes += int64(len(data))
+	u.saved[key] = "/images/" + name
+	return u.saved[key], nil
+}
+
+// readFile 在 ZIP 压缩包中查找图片文件。
+func (u *imageUploader) readFile(name, base string) ([]byte, error) {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	candidates := []string{path.Join(base, name), name, path.Join("source", name)}
+	for _, candidate := range candidates {
+		if f, ok := u.files[strings.TrimPrefix(path.Clean("/"+candidate), "/")]; ok {
+			if f.UncompressedSize64 > maxImportImage {
+				return nil, errEntryTooBig
+			}
+			return readZipFile(f)
+		}
+	}
+	return nil, errImageNotFound
+}
+
+// downloadImage 下载远程图片，超过 maxImportImage 时返回错误。
+func downloadImage(src string) ([]byte, error) {
+	resp, err := imageClient.Get(src)
+	if err != nil {
+		if errors.Is(err, errPrivateHost) {
+			return nil, errPrivateHost
+		}
+		return nil, errors.New("下载失败")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("下载失败：" + resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImportImage+1))
+	if err != nil {
+		return nil, errors.New("下载失败")
+	}
+	if len(data) > maxImportImage {
+		return nil, errEntryTooBig
+	}
+	return data, nil
+}
+
+// ensureImageDir 确保图片目录存在。
+func ensureImageDir() error {
+	return os.MkdirAll(imageDir, 0755)
+}
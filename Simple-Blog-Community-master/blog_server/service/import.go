@@ -0,0 +1,645 @@
+package service
+
+import (
+	"archive/zip"
+	"blog_server/model"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// service/import.go
+
+// ImportDir 是上传的导入文件的保存目录，导入完成后文件被删除。
+const ImportDir = "./imports"
+
+// importInterval 是检查待处理导入任务的间隔，新任务提交后会立即开始处理。
+const importInterval = time.Minute
+
+const (
+	maxImportEntry   = 10 << 20  // ZIP 压缩包中单个文件允许的最大大小
+	maxImportEntries = 5000      // ZIP 压缩包中允许的最多文件数
+	maxImportTotal   = 500 << 20 // ZIP 压缩包解压后允许的最大总大小
+	importWorkers    = 3         // 同时进行的导入任务数，同一用户的任务依次进行
+)
+
+var (
+	// importQueue 用于通知后台任务有新的导入任务。
+	importQueue = make(chan struct{}, 1)
+	// importSlots 限制同时进行的导入任务数。
+	importSlots = make(chan struct{}, importWorkers)
+)
+
+var (
+	errImportEmpty  = errors.New("文件中没有可以导入的文章")
+	errEntryTooBig  = errors.New("文件过大")
+	errZipTooLarge  = fmt.Errorf("压缩包中的文件过多或解压后超过 %dMB", maxImportTotal>>20)
+	jekyllFileName  = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+	frontMatterDate = []string{
+		"2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00",
+		"2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02", time.RFC1123Z,
+	}
+)
+
+// importPost 是从导入文件中解析出的一篇文章。
+type importPost struct {
+	Name       string    // 文章在导入文件中的位置
+	Title      string    // 标题
+	Content    string    // HTML 格式的正文
+	HeadImage  string    // 头图地址
+	Categories []string  // 分类名称
+	Tags       []string  // 标签名称
+	Date       time.Time // 发布时间
+	Updated    time.Time // 更新时间
+	Draft      bool      // 是否为草稿
+	Base       string    // 解析正文中相对路径图片时使用的目录
+	Error      string    // 文件无法读取时的原因
+}
+
+// QueueImport 通知后台任务尽快处理新的导入任务。
+func QueueImport() {
+	select {
+	case importQueue <- struct{}{}:
+	default:
+	}
+}
+
+// InitImporter 启动后台任务，分配待导入的任务给空闲的工作协程。服务重启前未完成的任务会重新导入，已导入的文章因同名被跳过。
+func InitImporter(db *gorm.DB) {
+	db.Model(&model.ImportJob{}).Where("status = ?", model.ImportRunning).UpdateColumn("status", model.ImportPending)
+	go func() {
+		ticker := time.NewTicker(importInterval)
+		defer ticker.Stop()
+		for {
+			if err := processImports(db); err != nil {
+				log.Println("process imports failed:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-importQueue:
+			}
+		}
+	}()
+}
+
+// processImports 按提交顺序启动待导入的任务，最多同时进行 importWorkers 个，
+// 同一用户已有正在进行的任务时跳过该用户，避免一个用户的大量导入占满所有工作协程。
+func processImports(db *gorm.DB) error {
+	var jobs []model.ImportJob
+	if err := db.Where("status = ?", model.ImportPending).Order("id").Find(&jobs).Error; err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		var running int
+		db.Model(&model.ImportJob{}).Where("user_id = ? AND status = ?", job.UserId, model.ImportRunning).Count(&running)
+		if running > 0 {
+			continue
+		}
+		select {
+		case importSlots <- struct{}{}:
+		default:
+			// 没有空闲的工作协程，等待任务完成后再分配
+			return nil
+		}
+		result := db.Model(&model.ImportJob{}).Where("id = ? AND status = ?", job.ID, model.ImportPending).
+			UpdateColumn("status", model.ImportRunning)
+		if result.Error != nil || result.RowsAffected != 1 {
+			<-importSlots
+			continue
+		}
+		go func(job model.ImportJob) {
+			defer func() {
+				<-importSlots
+				QueueImport()
+			}()
+			finishImport(db, &job)
+		}(job)
+	}
+	return nil
+}
+
+// finishImport 执行导入任务，记录结果并通知用户，完成后删除上传的文件。
+func finishImport(db *gorm.DB, job *model.ImportJob) {
+	defer os.Remove(job.File)
+	if err := runImport(db, job); err != nil {
+		log.Println("import failed:", err)
+		db.Model(job).Updates(map[string]interface{}{
+			"status": model.ImportFailed, "error": err.Error(), "imported": job.Imported, "skipped": job.Skipped, "failed": job.Failed, "finished_at": time.Now(),
+		})
+		Notify(db, job.UserId, 0, model.NotifySystem, strconv.Itoa(int(job.ID)), "文章导入失败："+err.Error())
+		return
+	}
+	db.Model(job).Updates(map[string]interface{}{
+		"status": model.ImportDone, "imported": job.Imported, "skipped": job.Skipped, "failed": job.Failed, "finished_at": time.Now(),
+	})
+	Notify(db, job.UserId, 0, model.NotifySystem, strconv.Itoa(int(job.ID)),
+		fmt.Sprintf("文章导入完成：成功 %d 篇，跳过 %d 篇，失败 %d 篇", job.Imported, job.Skipped, job.Failed))
+}
+
+// runImport 逐篇解析并导入文章，解析出一篇就写入一篇，不在内存中保存整个文件的文章。单篇文章失败不影响其他文章。
+func runImport(db *gorm.DB, job *model.ImportJob) error {
+	if err := ensureImageDir(); err != nil {
+		return err
+	}
+	categories := map[string]uint{}
+	var list []model.Category
+	db.Find(&list)
+	for _, category := range list {
+		categories[strings.ToLower(category.CategoryName)] = category.ID
+	}
+	images := newImageUploader(nil)
+	handle := func(post importPost) {
+		item := importArticle(db, job, post, categories, images)
+		item.JobId = job.ID
+		if err := db.Create(&item).Error; err != nil {
+			log.Println("write import item failed:", err)
+		}
+		switch item.Status {
+		case model.ImportItemImported:
+			job.Imported++
+		case model.ImportItemSkipped:
+			job.Skipped++
+		default:
+			job.Failed++
+		}
+	}
+	setTotal := func(total int) error {
+		if total == 0 {
+			return errImportEmpty
+		}
+		job.Total = total
+		return db.Model(job).UpdateColumn("total", total).Error
+	}
+	switch job.Source {
+	case model.ImportMarkdown:
+		r, err := zip.OpenReader(job.File)
+		if err != nil {
+			return errors.New("无法读取 ZIP 文件")
+		}
+		defer r.Close()
+		files, err := markdownFiles(r.File)
+		if err != nil {
+			return err
+		}
+		if err := setTotal(len(files)); err != nil {
+			return err
+		}
+		images = newImageUploader(r.File)
+		for _, f := range files {
+			handle(parseMarkdownFile(f))
+		}
+	case model.ImportWordPress:
+		f, err := os.Open(job.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// 第一遍只收集附件地址和文章数量，第二遍逐篇导入，特色图片的附件可能出现在文章之后
+		attachments := map[string]string{}
+		total := 0
+		err = scanWXR(f, func(item wxrItem) {
+			if item.PostType == "attachment" && item.AttachmentURL != "" {
+				attachments[item.PostId] = item.AttachmentURL
+			} else if item.PostType == "post" {
+				total++
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if err := setTotal(total); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return scanWXR(f, func(item wxrItem) {
+			if item.PostType == "post" {
+				handle(wxrPost(item, attachments))
+			}
+		})
+	default:
+		return errors.New("不支持的导入格式")
+	}
+	return nil
+}
+
+// importArticle 导入一篇文章：匹配分类、上传图片、检查敏感词后创建文章，返回导入结果。
+func importArticle(db *gorm.DB, job *model.ImportJob, post importPost, categories map[string]uint, images *imageUploader) model.ImportItem {
+	item := model.ImportItem{Name: truncate(post.Name, 255), Title: truncate(post.Title, 255)}
+	var notes []string
+	fail := func(status, msg string) model.ImportItem {
+		item.Status = status
+		item.Message = strings.Join(append([]string{msg}, notes...), "；")
+		return item
+	}
+	if post.Error != "" {
+		return fail(model.ImportItemFailed, post.Error)
+	}
+	title := strings.TrimSpace(post.Title)
+	if title == "" {
+		return fail(model.ImportItemFailed, "缺少标题")
+	}
+	if post.Draft {
+		return fail(model.ImportItemSkipped, "草稿或未公开的文章")
+	}
+	if utf8.RuneCountInString(title) > 50 {
+		title = string([]rune(title)[:50])
+		notes = append(notes, "标题超过 50 个字符，已截断")
+	}
+	if !db.Where("user_id = ? AND title = ?", job.UserId, title).First(&model.Article{}).RecordNotFound() {
+		return fail(model.ImportItemSkipped, "已存在同名文章")
+	}
+	if strings.TrimSpace(post.Content) == "" {
+		return fail(model.ImportItemFailed, "正文为空")
+	}
+	// 匹配分类，没有同名分类时使用默认分类
+	categoryId := job.CategoryId
+	matched := len(post.Categories) == 0
+	for _, name := range post.Categories {
+		if id, ok := categories[strings.ToLower(strings.TrimSpace(name))]; ok {
+			categoryId, matched = id, true
+			break
+		}
+	}
+	if !matched {
+		notes = append(notes, "分类 "+strings.Join(post.Categories, "、")+" 不存在，已使用默认分类")
+	}
+	if len(post.Tags) > 0 {
+		notes = append(notes, "暂不支持标签，已忽略："+strings.Join(post.Tags, "、"))
+	}
+	// 上传正文和头图中的图片
+	content, warnings := images.rewrite(post.Content, post.Base)
+	notes = append(notes, warnings...)
+	headImage := post.HeadImage
+	if headImage != "" {
+		if uploaded, err := images.upload(headImage, post.Base); err == nil {
+			headImage = uploaded
+		} else {
+			notes = append(notes, "头图 "+headImage+" 上传失败："+err.Error())
+		}
+	}
+	// 检查敏感词
+	filter := GetFilter()
+	titleResult, contentResult := filter.Check(title), filter.Check(content)
+	if titleResult.Action == model.WordReject || contentResult.Action == model.WordReject {
+		return fail(model.ImportItemFailed, "内容包含敏感词："+strings.Join(append(titleResult.Words, contentResult.Words...), "、"))
+	}
+	article := model.Article{
+		UserId:     job.UserId,
+		CategoryId: categoryId,
+		Title:      titleResult.Masked,
+		Content:    contentResult.Masked,
+		HeadImage:  headImage,
+		CreatedAt:  model.Time(post.Date),
+		UpdatedAt:  model.Time(post.Updated),
+	}
+	if post.Updated.IsZero() {
+		article.UpdatedAt = article.CreatedAt
+	}
+	if err := db.Create(&article).Error; err != nil {
+		return fail(model.ImportItemFailed, "保存失败")
+	}
+	if titleResult.Action == model.WordReview || contentResult.Action == model.WordReview {
+		FlagForReview(db, model.ReportArticle, article.ID.String(), article.UserId, append(titleResult.Words, contentResult.Words...))
+		notes = append(notes, "包含需要审核的内容，已提交版主审核")
+	}
+	item.Status = model.ImportItemImported
+	item.ArticleId = article.ID.String()
+	item.Message = strings.Join(notes, "；")
+	return item
+}
+
+// markdownFiles 检查 ZIP 压缩包的文件数和解压后的总大小，返回其中需要导入的 Markdown 文件。
+// 解压时 archive/zip 会校验实际大小与文件头中记录的大小一致，因此可以直接按文件头计算总大小。
+func markdownFiles(files []*zip.File) ([]*zip.File, error) {
+	if len(files) > maxImportEntries {
+		return nil, errZipTooLarge
+	}
+	var total uint64
+	var posts []*zip.File
+	for _, f := range files {
+		total += f.UncompressedSize64
+		if total > maxImportTotal {
+			return nil, errZipTooLarge
+		}
+		name := f.Name
+		ext := strings.ToLower(path.Ext(name))
+		if f.FileInfo().IsDir() || (ext != ".md" && ext != ".markdown") || hiddenPath(name) {
+			continue
+		}
+		posts = append(posts, f)
+	}
+	// Jekyll 和 Hexo 的文件名通常以日期开头，按文件名排序使文章大致按发布时间导入
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Name < posts[j].Name })
+	return posts, nil
+}
+
+// parseMarkdownFile 读取并解析 ZIP 压缩包中的一个 Markdown 文件，_drafts 目录中的文章视为草稿。
+func parseMarkdownFile(f *zip.File) importPost {
+	name := f.Name
+	post := importPost{Name: name, Base: path.Dir(name)}
+	data, err := readZipFile(f)
+	if err != nil {
+		post.Title, post.Error = path.Base(name), "无法读取文件："+err.Error()
+		return post
+	}
+	parseMarkdownPost(&post, string(data))
+	post.Draft = post.Draft || strings.Contains("/"+name, "/_drafts/")
+	return post
+}
+
+// parseMarkdownPost 解析 Markdown 文件的 front matter 和正文。没有标题和日期时，
+// 使用 Jekyll 风格的文件名（2006-01-02-标题.md）或正文中的一级标题。
+func parseMarkdownPost(post *importPost, text string) {
+	text = strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\ufeff")
+	meta := map[string]interface{}{}
+	if strings.HasPrefix(text, "---\n") {
+		if end := strings.Index(text[4:], "\n---"); end >= 0 {
+			yaml.Unmarshal([]byte(text[4:4+end]), &meta)
+			text = text[4+end+4:]
+			if i := strings.IndexByte(text, '\n'); i >= 0 {
+				text = text[i+1:]
+			} else {
+				text = ""
+			}
+		}
+	}
+	post.Title = metaString(meta, "title")
+	post.Date = metaTime(meta, "date")
+	post.Updated = metaTime(meta, "updated", "last_modified_at", "lastmod")
+	post.Categories = metaList(meta, "categories", "category")
+	post.Tags = metaList(meta, "tags", "tag")
+	post.HeadImage = metaString(meta, "cover", "thumbnail", "image", "banner", "index_img", "head_image")
+	if draft, ok := meta["draft"].(bool); ok && draft {
+		post.Draft = true
+	}
+	if published, ok := meta["published"].(bool); ok && !published {
+		post.Draft = true
+	}
+	base := strings.TrimSuffix(path.Base(post.Name), path.Ext(post.Name))
+	if m := jekyllFileName.FindStringSubmatch(base); m != nil {
+		if post.Date.IsZero() {
+			post.Date, _ = time.ParseInLocation("2006-01-02", m[1], time.Local)
+		}
+		base = m[2]
+	}
+	if post.Title == "" {
+		lines := strings.SplitN(strings.TrimSpace(text), "\n", 2)
+		if m := mdHeading.FindStringSubmatch(lines[0]); m != nil && len(m[1]) == 1 {
+			post.Title, text = m[2], ""
+			if len(lines) > 1 {
+				text = lines[1]
+			}
+		} else {
+			post.Title = base
+		}
+	}
+	post.Content = MarkdownToHTML(text)
+}
+
+// wxrItem 是 WordPress 导出文件（WXR）中一个条目需要的部分。
+type wxrItem struct {
+	Title         string `xml:"title"`
+	Link          string `xml:"link"`
+	PostId        string `xml:"post_id"`
+	PostDate      string `xml:"post_date"`
+	PostModified  string `xml:"post_modified"`
+	PostType      string `xml:"post_type"`
+	Status        string `xml:"status"`
+	Content       string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	AttachmentURL string `xml:"attachment_url"`
+	Categories    []struct {
+		Domain string `xml:"domain,attr"`
+		Name   string `xml:",chardata"`
+	} `xml:"category"`
+	Meta []struct {
+		Key   string `xml:"meta_key"`
+		Value string `xml:"meta_value"`
+	} `xml:"postmeta"`
+}
+
+// scanWXR 依次解析 WordPress 导出文件中的条目，每次只在内存中保存一个条目。
+func scanWXR(r io.Reader, fn func(item wxrItem)) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.New("无法解析 WordPress 导出文件")
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "item" {
+			continue
+		}
+		var item wxrItem
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return errors.New("无法解析 WordPress 导出文件")
+		}
+		fn(item)
+	}
+}
+
+// wxrPost 将 WordPress 的文章转换为待导入的文章，只导入已发布的文章，特色图片作为头图。
+func wxrPost(item wxrItem, attachments map[string]string) importPost {
+	post := importPost{
+		Name:    item.Link,
+		Title:   strings.TrimSpace(item.Title),
+		Content: autoParagraph(item.Content),
+		Date:    parseDate(item.PostDate),
+		Updated: parseDate(item.PostModified),
+		Draft:   item.Status != "publish",
+	}
+	if post.Name == "" {
+		post.Name = "post " + item.PostId
+	}
+	for _, category := range item.Categories {
+		switch category.Domain {
+		case "category":
+			post.Categories = append(post.Categories, strings.TrimSpace(category.Name))
+		case "post_tag":
+			post.Tags = append(post.Tags, strings.TrimSpace(category.Name))
+		}
+	}
+	for _, meta := range item.Meta {
+		if meta.Key == "_thumbnail_id" {
+			post.HeadImage = attachments[meta.Value]
+		}
+	}
+	return post
+}
+
+// autoParagraph 将 WordPress 经典编辑器中以空行分隔的段落转换为 <p> 标签，已经包含段落标签的正文不做处理。
+func autoParagraph(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if strings.Contains(content, "<p>") || strings.Contains(content, "<p ") {
+		return content
+	}
+	var out []string
+	for _, block := range strings.Split(content, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if isBlockHTML(block) {
+			out = append(out, block)
+		} else {
+			out = append(out, "<p>"+strings.ReplaceAll(block, "\n", "<br>\n")+"</p>")
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// isBlockHTML 判断文本是否以块级 HTML 标签或注释开头。
+func isBlockHTML(text string) bool {
+	lower := strings.ToLower(text)
+	for _, tag := range []string{"<!--", "<h", "<ul", "<ol", "<pre", "<blockquote", "<table", "<div", "<figure", "<hr"} {
+		if strings.HasPrefix(lower, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// readZipFile 读取 ZIP 压缩包中的文件，超过 maxImportEntry 时返回错误。
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(rc, maxImportEntry+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportEntry {
+		return nil, errEntryTooBig
+	}
+	return data, nil
+}
+
+// hiddenPath 判断路径中是否包含以 . 开头的目录或文件，以及 macOS 压缩时生成的 __MACOSX 目录。
+func hiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// metaString 返回 front matter 中第一个存在的字段的字符串值。
+func metaString(meta map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case string:
+			if v != "" {
+				return strings.TrimSpace(v)
+			}
+		case int, float64, bool:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// metaList 返回 front matter 中第一个存在的列表字段，字段可以是字符串、以逗号分隔的字符串或嵌套的列表。
+func metaList(meta map[string]interface{}, keys ...string) []string {
+	for _, key := range keys {
+		var list []string
+		var walk func(v interface{})
+		walk = func(v interface{}) {
+			switch v := v.(type) {
+			case string:
+				for _, s := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '，' }) {
+					if s = strings.TrimSpace(s); s != "" {
+						list = append(list, s)
+					}
+				}
+			case []interface{}:
+				for _, e := range v {
+					walk(e)
+				}
+			case nil:
+			default:
+				list = append(list, fmt.Sprint(v))
+			}
+		}
+		walk(meta[key])
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+// metaTime 返回 front matter 中第一个存在的时间字段。
+func metaTime(meta map[string]interface{}, keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			if t := parseDate(v); !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// parseDate 按常见的格式解析时间，无法解析时返回零值。
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000") {
+		return time.Time{}
+	}
+	for _, layout := range frontMatterDate {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// newImportPath 返回保存上传的导入文件的路径。
+func newImportPath(ext string) string {
+	return filepath.Join(ImportDir, uuid.NewV4().String()+ext)
+}
+
+// SaveImportFile 保存上传的导入文件，返回保存的路径。
+func SaveImportFile(r io.Reader, ext string) (string, error) {
+	if err := os.MkdirAll(ImportDir, 0700); err != nil {
+		return "", err
+	}
+	name := newImportPath(ext)
+	out, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, r); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
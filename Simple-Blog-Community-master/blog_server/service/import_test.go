@@ -0,0 +1,333 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMarkdownPost(t *testing.T) {
+	local := func(s string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+		return t
+	}
+	tests := []struct {
+		name       string
+		file       string
+		text       string
+		title      string
+		date       time.Time
+		categories []string
+		tags       []string
+		draft      bool
+		content    string
+	}{
+		{"Hexo 列表分类", "source/_posts/hello.md",
+			"---\ntitle: Hello\ndate: 2021-03-04 05:06:07\ncategories:\n- Go\n- Web\ntags: [a, b]\n---\n正文\n",
+			"Hello", local("2021-03-04 05:06:07"), []string{"Go", "Web"}, []string{"a", "b"}, false, "<p>正文</p>\n"},
+		{"Hexo 多级分类", "hello.md",
+			"---\ntitle: Hello\ncategories: [[Go, Web]]\n---\n",
+			"Hello", time.Time{}, []string{"Go", "Web"}, nil, false, ""},
+		{"Jekyll 字符串分类和文件名日期", "_posts/2020-01-02-my-post.md",
+			"---\nlayout: post\ntitle: \"Jekyll\"\ncategory: Go, Web\ntags: 随笔\n---\n正文",
+			"Jekyll", local("2020-01-02 00:00:00"), []string{"Go", "Web"}, []string{"随笔"}, false, "<p>正文</p>\n"},
+		{"front matter 的日期优先于文件名", "_posts/2020-01-02-my-post.md",
+			"---\ntitle: Jekyll\ndate: 2020-05-06 07:08:09 +0800\n---\n",
+			"Jekyll", time.Date(2020, 5, 6, 7, 8, 9, 0, time.FixedZone("", 8*3600)), nil, nil, false, ""},
+		{"RFC 3339 日期", "post.md",
+			"---\ntitle: T\ndate: 2021-03-04T05:06:07+08:00\n---\n",
+			"T", time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("", 8*3600)), nil, nil, false, ""},
+		{"无法解析的日期", "post.md",
+			"---\ntitle: T\ndate: yesterday\n---\n",
+			"T", time.Time{}, nil, nil, false, ""},
+		{"Hexo 草稿", "post.md",
+			"---\ntitle: T\ndraft: true\n---\n",
+			"T", time.Time{}, nil, nil, true, ""},
+		{"Jekyll 未发布", "post.md",
+			"---\ntitle: T\npublished: false\n---\n",
+			"T", time.Time{}, nil, nil, true, ""},
+		{"没有 front matter 时使用一级标题", "notes/post.md",
+			"# 标题\n\n正文",
+			"标题", time.Time{}, nil, nil, false, "<p>正文</p>\n"},
+		{"二级标题不作为文章标题", "notes/2019-07-08-some-post.md",
+			"## 小节\n正文",
+			"some-post", local("2019-07-08 00:00:00"), nil, nil, false, "<h2>小节</h2>\n<p>正文</p>\n"},
+		{"BOM 和 CRLF", "post.md",
+			"\ufeff---\r\ntitle: Windows\r\n---\r\n正文\r\n",
+			"Windows", time.Time{}, nil, nil, false, "<p>正文</p>\n"},
+		{"front matter 没有结束标记时按正文处理", "post.md",
+			"---\ntitle: T",
+			"post", time.Time{}, nil, nil, false, "<hr>\n<p>title: T</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := importPost{Name: tt.file}
+			parseMarkdownPost(&post, tt.text)
+			if post.Title != tt.title {
+				t.Errorf("title = %q, want %q", post.Title, tt.title)
+			}
+			if !post.Date.Equal(tt.date) {
+				t.Errorf("date = %v, want %v", post.Date, tt.date)
+			}
+			if !reflect.DeepEqual(post.Categories, tt.categories) {
+				t.Errorf("categories = %q, want %q", post.Categories, tt.categories)
+			}
+			if !reflect.DeepEqual(post.Tags, tt.tags) {
+				t.Errorf("tags = %q, want %q", post.Tags, tt.tags)
+			}
+			if post.Draft != tt.draft {
+				t.Errorf("draft = %v, want %v", post.Draft, tt.draft)
+			}
+			if post.Content != tt.content {
+				t.Errorf("content = %q, want %q", post.Content, tt.content)
+			}
+		})
+	}
+}
+
+func TestMetaList(t *testing.T) {
+	tests := []struct {
+		name string
+		meta map[string]interface{}
+		want []string
+	}{
+		{"字符串", map[string]interface{}{"categories": "Go"}, []string{"Go"}},
+		{"逗号分隔", map[string]interface{}{"categories": "Go, Web，随笔"}, []string{"Go", "Web", "随笔"}},
+		{"包含空格的名称", map[string]interface{}{"categories": "Hello World"}, []string{"Hello World"}},
+		{"列表", map[string]interface{}{"categories": []interface{}{"Go", 2021}}, []string{"Go", "2021"}},
+		{"嵌套列表", map[string]interface{}{"categories": []interface{}{[]interface{}{"Go", "Web"}, "随笔"}}, []string{"Go", "Web", "随笔"}},
+		{"使用后备字段", map[string]interface{}{"categories": "", "category": "Go"}, []string{"Go"}},
+		{"空值", map[string]interface{}{"categories": nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metaList(tt.meta, "categories", "category"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metaList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2021-03-04 05:06:07", time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)},
+		{" 2021-03-04 ", time.Date(2021, 3, 4, 0, 0, 0, 0, time.Local)},
+		{"2021-03-04 05:06", time.Date(2021, 3, 4, 5, 6, 0, 0, time.Local)},
+		{"2021-03-04T05:06:07", time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)},
+		{"2021-03-04T05:06:07Z", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"Thu, 04 Mar 2021 05:06:07 +0000", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"0000-00-00 00:00:00", time.Time{}},
+		{"", time.Time{}},
+		{"2021/03/04", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseDate(tt.in); !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// wxrFixture 是一个简化的 WordPress 导出文件，特色图片的附件出现在文章之后。
+const wxrFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Blog</title>
+	<item>
+		<title>第一篇 &amp; 文章</title>
+		<link>https://example.com/first</link>
+		<content:encoded><![CDATA[第一段
+第二行
+
+第二段]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_date>2020-01-02 03:04:05</wp:post_date>
+		<wp:post_modified>2020-02-03 04:05:06</wp:post_modified>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="go"><![CDATA[Go]]></category>
+		<category domain="post_tag" nicename="web"><![CDATA[Web]]></category>
+		<wp:postmeta>
+			<wp:meta_key>_thumbnail_id</wp:meta_key>
+			<wp:meta_value>10</wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title>草稿</title>
+		<content:encoded><![CDATA[<p>未完成</p>]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_date>0000-00-00 00:00:00</wp:post_date>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>关于</title>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>cover</title>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:attachment_url>https://example.com/cover.jpg</wp:attachment_url>
+	</item>
+</channel>
+</rss>`
+
+func TestScanWXR(t *testing.T) {
+	// 与 runImport 相同，第一遍收集附件，第二遍转换文章
+	r := strings.NewReader(wxrFixture)
+	attachments := map[string]string{}
+	types := []string{}
+	if err := scanWXR(r, func(item wxrItem) {
+		types = append(types, item.PostType)
+		if item.PostType == "attachment" {
+			attachments[item.PostId] = item.AttachmentURL
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"post", "post", "page", "attachment"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("item types = %q, want %q", types, want)
+	}
+	r.Seek(0, 0)
+	var posts []importPost
+	if err := scanWXR(r, func(item wxrItem) {
+		if item.PostType == "post" {
+			posts = append(posts, wxrPost(item, attachments))
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+
+	first := posts[0]
+	want := importPost{
+		Name:       "https://example.com/first",
+		Title:      "第一篇 & 文章",
+		Content:    "<p>第一段<br>\n第二行</p>\n<p>第二段</p>",
+		HeadImage:  "https://example.com/cover.jpg",
+		Categories: []string{"Go"},
+		Tags:       []string{"Web"},
+		Date:       time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local),
+		Updated:    time.Date(2020, 2, 3, 4, 5, 6, 0, time.Local),
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("first post = %+v, want %+v", first, want)
+	}
+
+	draft := posts[1]
+	if !draft.Draft || draft.Name != "post 2" || !draft.Date.IsZero() || draft.Content != "<p>未完成</p>" {
+		t.Errorf("draft post = %+v", draft)
+	}
+
+	if err := scanWXR(strings.NewReader("<rss><channel><item><title>x</title>"), func(wxrItem) {}); err == nil {
+		t.Error("truncated file should fail to parse")
+	}
+}
+
+func TestAutoParagraph(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"空行分段", "a\n\nb", "<p>a</p>\n<p>b</p>"},
+		{"单个换行", "a\r\nb", "<p>a<br>\nb</p>"},
+		{"多余的空行", "\n\na\n\n\n\nb\n", "<p>a</p>\n<p>b</p>"},
+		{"块级标签不加段落", "<h2>标题</h2>\n\n正文\n\n<!-- more -->", "<h2>标题</h2>\n<p>正文</p>\n<!-- more -->"},
+		{"已有段落标签", "<p>a</p>\n\nb", "<p>a</p>\n\nb"},
+		{"带属性的段落标签", "<p class=\"x\">a</p>\n\nb", "<p class=\"x\">a</p>\n\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoParagraph(tt.in); got != tt.want {
+				t.Errorf("autoParagraph() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"标题和段落", "# 标题 #\n第一行\n第二行", "<h1>标题</h1>\n<p>第一行\n第二行</p>\n"},
+		{"代码块转义且不转换行内语法", "```go\nif a < b && *p* {\n}\n```", "<pre><code class=\"language-go\">if a &lt; b &amp;&amp; *p* {\n}</code></pre>\n"},
+		{"波浪线代码块", "~~~\n# 不是标题\n~~~\n正文", "<pre><code># 不是标题</code></pre>\n<p>正文</p>\n"},
+		{"未闭合的代码块", "```\ncode", "<pre><code>code</code></pre>\n"},
+		{"转义 < 和 &", "a < b & c", "<p>a &lt; b &amp; c</p>\n"},
+		{"保留实体和行内标签", "&copy; <span>x</span>", "<p>&copy; <span>x</span></p>\n"},
+		{"行内代码转义", "用 `<div>` 和 `a && b`", "<p>用 <code>&lt;div&gt;</code> 和 <code>a &amp;&amp; b</code></p>\n"},
+		{"图片和链接", "![图](a.png) [链接](https://example.com \"t\")", "<p><img src=\"a.png\" alt=\"图\"> <a href=\"https://example.com\">链接</a></p>\n"},
+		{"粗体和斜体", "**粗** 和 *斜*", "<p><strong>粗</strong> 和 <em>斜</em></p>\n"},
+		{"下划线不在单词中间转换", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"无序和有序列表", "- a\n- b\n\n1. c\n2) d", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol>\n<li>c</li>\n<li>d</li>\n</ol>\n"},
+		{"嵌套列表展开为同一层", "- a\n  - b\n- c", "<ul>\n<li>a</li>\n<li>b</li>\n<li>c</li>\n</ul>\n"},
+		{"更深的缩进作为续行", "- a\n    继续", "<ul>\n<li>a</li>\n<p>继续</p>\n</ul>\n"},
+		{"列表类型切换", "- a\n1. b", "<ul>\n<li>a</li>\n</ul>\n<ol>\n<li>b</li>\n</ol>\n"},
+		{"引用", "> 引用\n> **粗**\n\n正文", "<blockquote><p>引用\n<strong>粗</strong></p>\n</blockquote>\n<p>正文</p>\n"},
+		{"分割线", "a\n\n---\n\nb", "<p>a</p>\n<hr>\n<p>b</p>\n"},
+		{"原始 HTML 块", "<div class=\"x\">\n<b>a</b>\n</div>", "<div class=\"x\">\n<b>a</b>\n</div>\n"},
+		{"行尾两个空格换行", "a  \nb", "<p>a<br>\nb</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToHTML(tt.in); got != tt.want {
+				t.Errorf("MarkdownToHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageReadFile(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"source/images/a.png", "posts/img/b.png", "c.png", "posts/图 片.png"} {
+		f, _ := w.Create(name)
+		f.Write([]byte(name))
+	}
+	w.Close()
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := newImageUploader(r.File)
+	tests := []struct {
+		name string
+		src  string
+		base string
+		want string // 读取到的文件，为空表示找不到
+	}{
+		{"相对于文章目录", "img/b.png", "posts", "posts/img/b.png"},
+		{"相对于压缩包根目录", "c.png", "posts", "c.png"},
+		{"Hexo 的 source 目录", "/images/a.png", "source/_posts", "source/images/a.png"},
+		{"上级目录", "../c.png", "posts", "c.png"},
+		{"URL 编码的文件名", "%E5%9B%BE%20%E7%89%87.png", "posts", "posts/图 片.png"},
+		{"不能跳出压缩包", "../../../c.png/../../etc/passwd", "posts", ""},
+		{"不存在的文件", "missing.png", "posts", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := u.readFile(tt.src, tt.base)
+			if tt.want == "" {
+				if err != errImageNotFound {
+					t.Errorf("readFile() error = %v, want %v", err, errImageNotFound)
+				}
+				return
+			}
+			if err != nil || string(data) != tt.want {
+				t.Errorf("readFile() = %q, %v, want %q", data, err, tt.want)
+			}
+		})
+	}
+}
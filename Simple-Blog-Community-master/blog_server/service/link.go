@@ -0,0 +1,63 @@
+package service
+
+import (
+	"blog_server/common"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// service/link.go
+
+// 签名链接的用途，不同用途的签名互不通用。
+const (
+	LinkVerifyEmail = "verify_email" // 验证邮箱
+	LinkResetEmail  = "reset_email"  // 通过邮件重置密码
+	LinkTwoFactor   = "two_factor"   // 登录时两步验证的挑战令牌
+	LinkOAuthState  = "oauth_state"  // 外部登录的 state 参数
+	LinkOAuthBind   = "oauth_bind"   // 已登录用户绑定外部登录账号
+	LinkOAuthLink   = "oauth_link"   // 外部登录服务回调后等待用户确认的绑定
+)
+
+// ErrLinkInvalid 表示链接无效或已过期。
+var ErrLinkInvalid = errors.New("链接无效或已过期")
+
+// NewSignedToken 生成带签名和过期时间的令牌，用于邮件中的链接。
+// value 是需要与用户当前状态比对的值，例如待验证的邮箱或 token 版本，状态改变后令牌自动失效。
+func NewSignedToken(purpose string, userId uint, value string, ttl time.Duration) string {
+	payload := strings.Join([]string{strconv.Itoa(int(userId)), value, strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(common.Sign(purpose+"|"+payload))
+}
+
+// ParseSignedToken 校验令牌的签名和过期时间，返回其中的用户 ID 和值。
+func ParseSignedToken(purpose, token string) (uint, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return 0, "", ErrLinkInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, "", ErrLinkInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, common.Sign(purpose+"|"+string(payload))) {
+		return 0, "", ErrLinkInvalid
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 3 {
+		return 0, "", ErrLinkInvalid
+	}
+	userId, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, "", ErrLinkInvalid
+	}
+	expires, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, "", ErrLinkInvalid
+	}
+	return uint(userId), fields[1], nil
+}
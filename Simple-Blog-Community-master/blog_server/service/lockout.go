@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// service/lockout.go
+
+// LockoutStore 是登录失败记录的存储后端。
+type LockoutStore interface {
+	// Locked 返回 key 剩余的锁定时间，未锁定时返回 0。
+	Locked(key string) (time.Duration, error)
+	// Fail 记录一次失败，连续失败达到 threshold 次后锁定 base，之后每次失败锁定时间加倍，最多 max；
+	// 超过 reset 没有失败时清零失败次数。返回因此产生的锁定时长。
+	Fail(key string, threshold int, base, max, reset time.Duration) (time.Duration, error)
+	// Reset 清除 key 的失败记录。
+	Reset(key string) error
+}
+
+var lockoutStore LockoutStore = NewMemoryLockout()
+
+// LoginGuard 记录登录失败次数，连续失败达到阈值后锁定，之后每次失败锁定时间加倍。
+// 失败记录保存在 lockoutStore 中，配置 Redis 时多个服务实例共享，且不会因重启而丢失。
+type LoginGuard struct {
+	name      string        // 区分不同记录的前缀
+	threshold int           // 开始锁定的失败次数
+	base      time.Duration // 第一次锁定的时长
+	max       time.Duration // 锁定时长上限
+	reset     time.Duration // 超过该时间没有失败时清零失败次数
+}
+
+var (
+	// phoneGuard 按账号记录登录失败，防止针对单个账号的暴力破解，键见 LoginKey。
+	phoneGuard = NewLoginGuard("account", 5, time.Minute, time.Hour, time.Hour)
+	// ipGuard 按 IP 记录登录失败，防止同一来源尝试大量账号，阈值较高以避免误伤共享出口 IP 的用户。
+	ipGuard = NewLoginGuard("ip", 20, time.Minute, time.Hour, time.Hour)
+)
+
+// NewLoginGuard 创建登录失败记录。
+func NewLoginGuard(name string, threshold int, base, max, reset time.Duration) *LoginGuard {
+	return &LoginGuard{name: name, threshold: threshold, base: base, max: max, reset: reset}
+}
+
+// Locked 返回 key 剩余的锁定时间，未锁定时返回 0。存储后端出错时视为未锁定。
+func (g *LoginGuard) Locked(key string) time.Duration {
+	wait, err := lockoutStore.Locked(g.name + ":" + key)
+	if err != nil {
+		log.Println("lockout store failed:", err)
+	}
+	return wait
+}
+
+// Fail 记录一次登录失败，达到阈值时锁定并返回锁定时长。
+func (g *LoginGuard) Fail(key string) time.Duration {
+	wait, err := lockoutStore.Fail(g.name+":"+key, g.threshold, g.base, g.max, g.reset)
+	if err != nil {
+		log.Println("lockout store failed:", err)
+	}
+	return wait
+}
+
+// Reset 清除 key 的失败记录。
+func (g *LoginGuard) Reset(key string) {
+	if err := lockoutStore.Reset(g.name + ":" + key); err != nil {
+		log.Println("lockout store failed:", err)
+	}
+}
+
+// MemoryLockout 是基于内存的登录失败记录，只在单个服务实例内有效。
+type MemoryLockout struct {
+	mu      sync.Mutex
+	records map[string]*loginRecord
+	now     func() time.Time // 返回当前时间，测试时可以替换
+}
+
+// loginRecord 是一个账号或 IP 的登录失败记录。
+type loginRecord struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+	reset       time.Duration
+}
+
+// NewMemoryLockout 创建内存登录失败记录，并定期清理过期的记录。
+func NewMemoryLockout() *MemoryLockout {
+	m := &MemoryLockout{records: make(map[string]*loginRecord), now: time.Now}
+	go func() {
+		for range time.Tick(time.Minute) {
+			m.cleanup()
+		}
+	}()
+	return m
+}
+
+// Locked 实现 LockoutStore 接口。
+func (m *MemoryLockout) Locked(key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.records[key]; ok {
+		if wait := r.lockedUntil.Sub(m.now()); wait > 0 {
+			return wait, nil
+		}
+	}
+	return 0, nil
+}
+
+// Fail 实现 LockoutStore 接口。
+func (m *MemoryLockout) Fail(key string, threshold int, base, max, reset time.Duration) (time.Duration, error) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[key]
+	if !ok || now.Sub(r.lastFailure) > reset {
+		r = &loginRecord{}
+		m.records[key] = r
+	}
+	r.failures++
+	r.lastFailure = now
+	r.reset = reset
+	if r.failures < threshold {
+		return 0, nil
+	}
+	lock := base
+	for i := threshold; i < r.failures && lock < max; i++ {
+		lock *= 2
+	}
+	if lock > max {
+		lock = max
+	}
+	r.lockedUntil = now.Add(lock)
+	return lock, nil
+}
+
+// Reset 实现 LockoutStore 接口。
+func (m *MemoryLockout) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+// cleanup 清理已经过期的失败记录。
+func (m *MemoryLockout) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, r := range m.records {
+		if now.Sub(r.lastFailure) > r.reset && now.After(r.lockedUntil) {
+			delete(m.records, key)
+		}
+	}
+}
+
+// lockoutFailScript 在 Redis 中原子地记录一次失败，返回锁定的毫秒数，算法与 MemoryLockout 相同。
+const lockoutFailScript = `
+local now = tonumber(ARGV[1])
+local threshold = tonumber(ARGV[2])
+local base = tonumber(ARGV[3])
+local max = tonumber(ARGV[4])
+local reset = tonumber(ARGV[5])
+local data = redis.call('HMGET', KEYS[1], 'failures', 'last', 'locked')
+local failures = tonumber(data[1]) or 0
+local last = tonumber(data[2]) or 0
+local locked = tonumber(data[3]) or 0
+if now - last > reset then
+	failures = 0
+	locked = 0
+end
+failures = failures + 1
+local lock = 0
+if failures >= threshold then
+	lock = base
+	for i = threshold, failures - 1 do
+		if lock >= max then break end
+		lock = lock * 2
+	end
+	if lock > max then lock = max end
+	locked = now + lock
+end
+redis.call('HMSET', KEYS[1], 'failures', failures, 'last', now, 'locked', locked)
+redis.call('PEXPIRE', KEYS[1], math.max(reset, lock) + 1000)
+return lock
+`
+
+// Locked 实现 LockoutStore 接口。
+func (r *RedisStore) Locked(key string) (time.Duration, error) {
+	reply, err := r.do("HGET", "lockout:"+key, "locked")
+	if err != nil {
+		return 0, err
+	}
+	s, _ := reply.(string)
+	if s == "" {
+		return 0, nil
+	}
+	locked, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if wait := time.Until(time.Unix(0, locked*int64(time.Millisecond))); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Fail 实现 LockoutStore 接口。
+func (r *RedisStore) Fail(key string, threshold int, base, max, reset time.Duration) (time.Duration, error) {
+	reply, err := r.do("EVAL", lockoutFailScript, "1", "lockout:"+key,
+		strconv.FormatInt(time.Now().UnixNano()/1e6, 10), strconv.Itoa(threshold),
+		strconv.FormatInt(base.Milliseconds(), 10), strconv.FormatInt(max.Milliseconds(), 10),
+		strconv.FormatInt(reset.Milliseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	lock, ok := reply.(int64)
+	if !ok {
+		return 0, errors.New("unexpected redis reply")
+	}
+	return time.Duration(lock) * time.Millisecond, nil
+}
+
+// Reset 实现 LockoutStore 接口。
+func (r *RedisStore) Reset(key string) error {
+	_, err := r.do("DEL", "lockout:"+key)
+	return err
+}
+
+// LoginKey 返回按账号记录登录失败时使用的键。账号存在时使用用户 ID，使密码错误和两步验证码错误合并计数，
+// 且不受登录时使用手机号还是邮箱的影响；账号不存在时使用登录时输入的账号。
+func LoginKey(userId uint, account string) string {
+	if userId != 0 {
+		return "user:" + strconv.Itoa(int(userId))
+	}
+	return "account:" + account
+}
+
+// LoginLocked 返回账号或 IP 剩余的锁定时间，两者都未锁定时返回 0。
+func LoginLocked(account, ip string) time.Duration {
+	wait := phoneGuard.Locked(account)
+	if ipWait := ipGuard.Locked(ip); ipWait > wait {
+		wait = ipWait
+	}
+	return wait
+}
+
+// LoginFailed 记录一次登录失败，返回因此产生的锁定时长。
+func LoginFailed(account, ip string) time.Duration {
+	wait := phoneGuard.Fail(account)
+	if ipWait := ipGuard.Fail(ip); ipWait > wait {
+		wait = ipWait
+	}
+	return wait
+}
+
+// LoginSucceeded 登录成功后清除账号的失败记录。IP 的记录保留，避免攻击者用自己的账号重置计数。
+func LoginSucceeded(account string) {
+	phoneGuard.Reset(account)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLockoutEscalation(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemoryLockout()
+	m.now = clock.Now
+	fail := func() time.Duration {
+		lock, err := m.Fail("k", 3, time.Minute, 4*time.Minute, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lock
+	}
+	locked := func() time.Duration {
+		wait, err := m.Locked("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wait
+	}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		lock    time.Duration // 本次失败产生的锁定时长
+	}{
+		{"第 1 次失败", 0, 0},
+		{"第 2 次失败", 0, 0},
+		{"达到阈值", 0, time.Minute},
+		{"锁定时间加倍", 0, 2 * time.Minute},
+		{"再次加倍", 0, 4 * time.Minute},
+		{"不超过上限", 0, 4 * time.Minute},
+		{"间隔未超过清零时间时继续累计", 59 * time.Minute, 4 * time.Minute},
+		{"超过清零时间后重新计数", time.Hour + time.Second, 0},
+	}
+	for _, step := range steps {
+		clock.Add(step.advance)
+		if lock := fail(); lock != step.lock {
+			t.Errorf("%s: Fail() = %v, want %v", step.name, lock, step.lock)
+		}
+	}
+
+	m.Reset("k")
+	fail()
+	fail()
+	fail()
+	if wait := locked(); wait != time.Minute {
+		t.Fatalf("Locked() = %v, want %v", wait, time.Minute)
+	}
+	clock.Add(40 * time.Second)
+	if wait := locked(); wait != 20*time.Second {
+		t.Fatalf("Locked() = %v, want %v", wait, 20*time.Second)
+	}
+	clock.Add(20 * time.Second)
+	if wait := locked(); wait != 0 {
+		t.Fatalf("Locked() after expiry = %v, want 0", wait)
+	}
+
+	// 清除后不再锁定，且重新开始计数
+	fail()
+	m.Reset("k")
+	if wait := locked(); wait != 0 {
+		t.Fatalf("Locked() after reset = %v, want 0", wait)
+	}
+	if lock := fail(); lock != 0 {
+		t.Fatalf("Fail() after reset = %v, want 0", lock)
+	}
+}
+
+func TestMemoryLockoutCleanup(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemoryLockout()
+	m.now = clock.Now
+	m.Fail("k", 1, 2*time.Hour, 2*time.Hour, time.Hour)
+	// 超过清零时间但仍在锁定中的记录不会被清理
+	clock.Add(time.Hour + time.Minute)
+	m.cleanup()
+	if _, ok := m.records["k"]; !ok {
+		t.Fatal("locked record removed")
+	}
+	clock.Add(time.Hour)
+	m.cleanup()
+	if _, ok := m.records["k"]; ok {
+		t.Fatal("expired record was not removed")
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemoryLockout()
+	m.now = clock.Now
+	saved := lockoutStore
+	lockoutStore = m
+	defer func() { lockoutStore = saved }()
+
+	const ip = "203.0.113.1"
+	// 密码错误和两步验证码错误使用同一个键，合并计数
+	password, code := LoginKey(7, "13800000000"), LoginKey(7, "")
+	if password != code {
+		t.Fatalf("LoginKey() = %q and %q, want the same key", password, code)
+	}
+	for i := 0; i < 4; i++ {
+		if wait := LoginFailed(code, ip); wait != 0 {
+			t.Fatalf("failure %d locked for %v", i+1, wait)
+		}
+	}
+	if wait := LoginFailed(password, ip); wait != time.Minute {
+		t.Fatalf("LoginFailed() = %v, want %v", wait, time.Minute)
+	}
+	if wait := LoginLocked(code, ip); wait != time.Minute {
+		t.Fatalf("LoginLocked() = %v, want %v", wait, time.Minute)
+	}
+	// 登录成功只清除账号的记录，IP 的失败次数继续累计
+	LoginSucceeded(code)
+	if wait := LoginLocked(code, ip); wait != 0 {
+		t.Fatalf("LoginLocked() after success = %v, want 0", wait)
+	}
+	for i := 0; i < 14; i++ {
+		LoginFailed(LoginKey(0, "other"+string(rune('a'+i))), ip)
+	}
+	if wait := LoginFailed(LoginKey(0, "another"), ip); wait != time.Minute {
+		t.Fatalf("IP lockout = %v, want %v", wait, time.Minute)
+	}
+	if wait := LoginLocked(LoginKey(8, ""), ip); wait != time.Minute {
+		t.Fatalf("LoginLocked() for another account from the locked IP = %v, want %v", wait, time.Minute)
+	}
+}
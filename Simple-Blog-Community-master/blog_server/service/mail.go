@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// service/mail.go
+
+// Mailer 是邮件的发送渠道。
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+var mailer Mailer = ConsoleMailer{}
+
+// InitMailer 根据配置选择邮件发送渠道：为空或 "console" 时输出到日志，"file:<路径>" 时追加写入文件，
+// "smtp://用户名:密码@主机:端口" 时通过 SMTP 发送，from 为发件人地址。
+func InitMailer(config, from string) Mailer {
+	switch {
+	case config == "" || config == "console":
+		mailer = ConsoleMailer{}
+	case strings.HasPrefix(config, "file:"):
+		mailer = &FileMailer{Path: strings.TrimPrefix(config, "file:")}
+	case strings.HasPrefix(config, "smtp://"):
+		u, err := url.Parse(config)
+		if err != nil {
+			log.Printf("invalid mailer config: %v, using console", err)
+			mailer = ConsoleMailer{}
+			break
+		}
+		password, _ := u.User.Password()
+		mailer = &SMTPMailer{Addr: u.Host, Username: u.User.Username(), Password: password, From: from}
+	default:
+		log.Printf("unknown mailer %q, using console", config)
+		mailer = ConsoleMailer{}
+	}
+	return mailer
+}
+
+// SetMailer 替换邮件发送渠道。
+func SetMailer(m Mailer) {
+	mailer = m
+}
+
+// GetMailer 返回当前的邮件发送渠道。
+func GetMailer() Mailer {
+	return mailer
+}
+
+// ConsoleMailer 将邮件内容输出到日志，仅用于开发环境。
+type ConsoleMailer struct{}
+
+// Send 实现 Mailer 接口。
+func (ConsoleMailer) Send(to, subject, body string) error {
+	log.Printf("[MAIL] to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// FileMailer 将邮件追加写入文件，用于开发和测试。
+type FileMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send 实现 Mailer 接口。
+func (f *FileMailer) Send(to, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "Date: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n.\r\n", time.Now().Format(time.RFC1123Z), to, subject, body)
+	return err
+}
+
+// SMTPMailer 通过 SMTP 服务器发送邮件，服务器支持时使用 STARTTLS。
+type SMTPMailer struct {
+	Addr     string // 主机:端口
+	Username string
+	Password string
+	From     string
+}
+
+// Send 实现 Mailer 接口。
+func (s *SMTPMailer) Send(to, subject, body string) error {
+	from := s.From
+	if from == "" {
+		from = s.Username
+	}
+	message := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, from, []string{to}, []byte(message))
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// service/markdown.go
+
+// 文章正文以 HTML 保存，导入的 Markdown 需要先转换为 HTML。这里只实现了博客文章中常用的语法：
+// 标题、段落、引用、列表、代码块、分割线、图片、链接、粗体、斜体和行内代码，以 < 开头的行按原始 HTML 保留。
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdFence    = regexp.MustCompile("^(```|~~~)\\s*([\\w+-]*)")
+	mdRule     = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	mdBullet   = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	mdOrdered  = regexp.MustCompile(`^\s{0,3}\d+[.)]\s+(.*)$`)
+	mdQuote    = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	mdCode     = regexp.MustCompile("`([^`]+)`")
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	mdBold     = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdItalic   = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]([^\w*]|$)`)
+	mdRawBlock = regexp.MustCompile(`^\s*<`)
+)
+
+// MarkdownToHTML 将 Markdown 转换为 HTML。
+func MarkdownToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var out strings.Builder
+	var paragraph, quote []string
+	list := ""
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + inlineMarkdown(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	flushQuote := func() {
+		if len(quote) > 0 {
+			out.WriteString("<blockquote>" + MarkdownToHTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+			quote = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	flush := func() {
+		flushParagraph()
+		flushQuote()
+		closeList()
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if m[2] != "" {
+				class = ` class="language-` + html.EscapeString(m[2]) + `"`
+			}
+			out.WriteString("<pre><code" + class + ">" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+			continue
+		}
+		if m := mdQuote.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			closeList()
+			quote = append(quote, m[1])
+			continue
+		}
+		flushQuote()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+			closeList()
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + inlineMarkdown(m[2]) + "</h" + level + ">\n")
+		case mdRule.MatchString(line):
+			flush()
+			out.WriteString("<hr>\n")
+		case mdBullet.MatchString(line) || mdOrdered.MatchString(line):
+			flushParagraph()
+			kind, m := "ul", mdBullet.FindStringSubmatch(line)
+			if m == nil {
+				kind, m = "ol", mdOrdered.FindStringSubmatch(line)
+			}
+			if list != kind {
+				closeList()
+				out.WriteString("<" + kind + ">\n")
+				list = kind
+			}
+			out.WriteString("<li>" + inlineMarkdown(m[1]) + "</li>\n")
+		case len(paragraph) == 0 && list == "" && mdRawBlock.MatchString(line):
+			out.WriteString(line + "\n")
+		case list != "" && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")):
+			// 列表项的续行
+			out.WriteString("<p>" + inlineMarkdown(strings.TrimSpace(line)) + "</p>\n")
+		default:
+			closeList()
+			// 保留行尾的两个空格，由 inlineMarkdown 转换为换行
+			text := strings.TrimSpace(line)
+			if strings.HasSuffix(line, "  ") {
+				text += "  "
+			}
+			paragraph = append(paragraph, text)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+// inlineMarkdown 转换行内语法。行内代码中的内容不再做其他转换。
+func inlineMarkdown(text string) string {
+	var codes []string
+	text = mdCode.ReplaceAllStringFunc(text, func(s string) string {
+		codes = append(codes, "<code>"+html.EscapeString(s[1:len(s)-1])+"</code>")
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+	text = escapeText(text)
+	text = mdImage.ReplaceAllString(text, `<img src="$2" alt="$1">`)
+	text = mdLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = mdBold.ReplaceAllString(text, "<strong>$2</strong>")
+	text = mdItalic.ReplaceAllString(text, "$1<em>$2</em>$3")
+	text = strings.ReplaceAll(text, "  \n", "<br>\n")
+	for i, code := range codes {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", code, 1)
+	}
+	return text
+}
+
+// escapeText 转义正文中的 & 和 <，但保留行内的 HTML 标签。
+func escapeText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '&':
+			if j := strings.IndexByte(text[i:], ';'); j > 1 && j < 10 && !strings.ContainsAny(text[i+1:i+j], " &<") {
+				b.WriteByte('&')
+			} else {
+				b.WriteString("&amp;")
+			}
+		case '<':
+			if i+1 < len(text) && (text[i+1] == '/' || text[i+1] == '!' || isASCIILetter(text[i+1])) {
+				b.WriteByte('<')
+			} else {
+				b.WriteString("&lt;")
+			}
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// isASCIILetter 判断 c 是否为 ASCII 字母。
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+)
+
+// service/notify.go
+
+// Notify 为用户记录一条通知。通知自己、接收者屏蔽了该类型的通知或者屏蔽了操作者时不做记录。
+func Notify(db *gorm.DB, userId, actorId uint, kind, targetId, content string) error {
+	if userId == 0 || userId == actorId {
+		return nil
+	}
+	if actorId != 0 && IsBlocked(db, userId, actorId) {
+		return nil
+	}
+	var muted int
+	db.Model(model.NotificationPreference{}).Where("user_id = ? AND type = ?", userId, kind).Count(&muted)
+	if muted > 0 {
+		return nil
+	}
+	notification := model.Notification{
+		UserId:   userId,
+		ActorId:  actorId,
+		Type:     kind,
+		TargetId: targetId,
+		Content:  content,
+	}
+	if err := db.Create(&notification).Error; err != nil {
+		return err
+	}
+	// 实时推送给接收者
+	hub.Publish(userId, "notification", notification)
+	return nil
+}
+
+// IsNotificationType 判断通知类型是否合法。
+func IsNotificationType(kind string) bool {
+	for _, t := range model.NotificationTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
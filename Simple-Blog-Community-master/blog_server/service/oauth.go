@@ -0,0 +1,269 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// service/oauth.go
+
+// OAuthProvider 是一个外部登录服务的配置。配置了 Issuer 时通过 OpenID Connect Discovery
+// 获取各个地址，否则需要配置 AuthURL、TokenURL 和 UserInfoURL。
+type OAuthProvider struct {
+	Name         string   `json:"name"`          // 标识，用于回调地址，例如 google
+	DisplayName  string   `json:"display_name"`  // 显示名称
+	Issuer       string   `json:"issuer"`        // OpenID Connect 的 issuer 地址
+	AuthURL      string   `json:"auth_url"`      // 授权地址
+	TokenURL     string   `json:"token_url"`     // 获取 access token 的地址
+	UserInfoURL  string   `json:"userinfo_url"`  // 获取用户信息的地址
+	ClientID     string   `json:"client_id"`     // 应用 ID
+	ClientSecret string   `json:"client_secret"` // 应用密钥
+	Scopes       []string `json:"scopes"`        // 申请的权限，默认为 openid profile email
+	IDField      string   `json:"id_field"`      // 用户信息中唯一标识的字段，默认为 sub
+	NameField    string   `json:"name_field"`    // 用户信息中昵称的字段，默认为 name
+
+	discovered bool
+}
+
+// ExternalUser 是从外部登录服务获取的用户信息。
+type ExternalUser struct {
+	Subject       string // 外部账号的唯一标识
+	Name          string // 昵称
+	Email         string // 邮箱
+	EmailVerified bool   // 外部登录服务是否验证过邮箱
+	Picture       string // 头像地址
+}
+
+// maxOAuthResponse 是外部登录服务响应允许的最大大小。
+const maxOAuthResponse = 1 << 20
+
+var (
+	oauthProviders = map[string]*OAuthProvider{}
+	oauthMu        sync.Mutex
+	oauthClient    = &http.Client{Timeout: 10 * time.Second}
+)
+
+// InitOAuthProviders 从 JSON 配置文件加载外部登录服务，文件内容为 OAuthProvider 数组。
+// path 为空时不启用外部登录。
+func InitOAuthProviders(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var providers []*OAuthProvider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return err
+	}
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	for _, p := range providers {
+		if p.Name == "" || p.ClientID == "" {
+			return errors.New("oauth provider requires name and client_id")
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "profile", "email"}
+		}
+		if p.IDField == "" {
+			p.IDField = "sub"
+		}
+		if p.NameField == "" {
+			p.NameField = "name"
+		}
+		if p.DisplayName == "" {
+			p.DisplayName = p.Name
+		}
+		oauthProviders[p.Name] = p
+	}
+	log.Printf("loaded %d oauth providers", len(providers))
+	return nil
+}
+
+// OAuthProviders 返回已配置的外部登录服务。
+func OAuthProviders() []*OAuthProvider {
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	providers := make([]*OAuthProvider, 0, len(oauthProviders))
+	for _, p := range oauthProviders {
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// GetOAuthProvider 返回指定的外部登录服务，配置了 Issuer 时会在第一次使用时获取 Discovery 文档。
+// 获取文档时不持有锁，避免外部登录服务响应缓慢时阻塞其他请求。
+func GetOAuthProvider(name string) (*OAuthProvider, error) {
+	oauthMu.Lock()
+	p, ok := oauthProviders[name]
+	discovered := ok && (p.Issuer == "" || p.discovered)
+	oauthMu.Unlock()
+	if !ok {
+		return nil, errors.New("不支持的登录方式")
+	}
+	if discovered {
+		return p, nil
+	}
+	doc, err := fetchDiscovery(p.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %v", p.Name, err)
+	}
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	if !p.discovered {
+		p.apply(doc)
+	}
+	return p, nil
+}
+
+// discoveryDocument 是 OpenID Connect Discovery 文档中需要的部分。
+type discoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// fetchDiscovery 获取 OpenID Connect Discovery 文档。
+func fetchDiscovery(issuer string) (discoveryDocument, error) {
+	var doc discoveryDocument
+	err := getJSON(strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", "", &doc)
+	return doc, err
+}
+
+// apply 使用 Discovery 文档中的地址，已配置的地址不会被覆盖，调用方需持有 oauthMu。
+func (p *OAuthProvider) apply(doc discoveryDocument) {
+	if p.AuthURL == "" {
+		p.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.TokenURL == "" {
+		p.TokenURL = doc.TokenEndpoint
+	}
+	if p.UserInfoURL == "" {
+		p.UserInfoURL = doc.UserinfoEndpoint
+	}
+	p.discovered = true
+}
+
+// NewPKCEVerifier 生成 PKCE 的 code_verifier。
+func NewPKCEVerifier() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AuthCodeURL 生成跳转到外部登录服务的授权地址，使用 PKCE 防止授权码被截获后使用。
+func (p *OAuthProvider) AuthCodeURL(redirectURI, state, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.ClientID)
+	values.Set("redirect_uri", redirectURI)
+	values.Set("scope", strings.Join(p.Scopes, " "))
+	values.Set("state", state)
+	values.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	values.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.AuthURL + separator + values.Encode()
+}
+
+// Exchange 使用授权码换取 access token，并获取外部账号的用户信息。
+func (p *OAuthProvider) Exchange(code, redirectURI, verifier string) (ExternalUser, error) {
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURI)
+	values.Set("client_id", p.ClientID)
+	values.Set("client_secret", p.ClientSecret)
+	values.Set("code_verifier", verifier)
+	request, _ := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(values.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	resp, err := oauthClient.Do(request)
+	if err != nil {
+		return ExternalUser{}, err
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOAuthResponse)).Decode(&token); err != nil {
+		return ExternalUser{}, err
+	}
+	if token.AccessToken == "" {
+		return ExternalUser{}, fmt.Errorf("token exchange failed: %s", token.Error)
+	}
+	return p.userInfo(token.AccessToken)
+}
+
+// userInfo 使用 access token 获取用户信息。
+func (p *OAuthProvider) userInfo(accessToken string) (ExternalUser, error) {
+	var info map[string]interface{}
+	if err := getJSON(p.UserInfoURL, accessToken, &info); err != nil {
+		return ExternalUser{}, err
+	}
+	user := ExternalUser{
+		Subject: claimString(info[p.IDField]),
+		Name:    claimString(info[p.NameField]),
+		Email:   strings.ToLower(claimString(info["email"])),
+		Picture: claimString(info["picture"]),
+	}
+	switch v := info["email_verified"].(type) {
+	case bool:
+		user.EmailVerified = v
+	case string:
+		user.EmailVerified = v == "true"
+	}
+	if user.Subject == "" {
+		return user, errors.New("userinfo has no " + p.IDField)
+	}
+	return user, nil
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应，accessToken 不为空时作为 Bearer token。
+func getJSON(address, accessToken string, v interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	resp, err := oauthClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", address, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOAuthResponse)).Decode(v)
+}
+
+// claimString 将用户信息中的字段转为字符串，数字类型的 ID（如 GitHub）同样适用。
+func claimString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
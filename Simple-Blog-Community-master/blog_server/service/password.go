@@ -0,0 +1,80 @@
+package service
+
+import (
+	"blog_server/model"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"unicode"
+)
+
+// service/password.go
+
+const (
+	passwordMinLength = 8
+	passwordMaxLength = 72 // bcrypt 只使用前 72 个字节
+)
+
+// commonPasswords 是常见的弱密码，满足长度和字符要求也不允许使用。
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "qwerty123": true, "abc12345": true, "abcd1234": true,
+	"1qaz2wsx": true, "a1234567": true, "12345678a": true, "iloveyou1": true, "admin123": true,
+}
+
+// 密码强度相关的错误，错误信息可以直接返回给用户。
+var (
+	ErrPasswordLength   = errors.New("密码长度需要在 8 到 72 个字符之间")
+	ErrPasswordWeak     = errors.New("密码需要同时包含字母和数字")
+	ErrPasswordCommon   = errors.New("密码过于常见，请更换")
+	ErrPasswordPersonal = errors.New("密码不能与手机号或用户名相同")
+)
+
+// CheckPassword 检查密码强度：长度在 8 到 72 之间，同时包含字母和数字，
+// 不能是常见的弱密码，也不能包含手机号或与用户名相同。
+func CheckPassword(password, phoneNumber, userName string) error {
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return ErrPasswordLength
+	}
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return ErrPasswordWeak
+	}
+	if commonPasswords[strings.ToLower(password)] {
+		return ErrPasswordCommon
+	}
+	if (phoneNumber != "" && strings.Contains(password, phoneNumber)) ||
+		(userName != "" && strings.EqualFold(password, userName)) {
+		return ErrPasswordPersonal
+	}
+	return nil
+}
+
+// SetPassword 更新用户的密码，并递增 token 版本、注销所有会话，使之前发放的 token 全部失效。
+// 返回更新后的用户，用于为当前会话重新发放 token。
+func SetPassword(db *gorm.DB, user model.User, password string) (model.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return user, err
+	}
+	err = db.Model(&user).Updates(map[string]interface{}{
+		"password":      string(hashedPassword),
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error
+	if err != nil {
+		return user, err
+	}
+	if err = RevokeSessions(db, user.ID, ""); err != nil {
+		return user, err
+	}
+	err = db.Where("id = ?", user.ID).First(&user).Error
+	return user, err
+}
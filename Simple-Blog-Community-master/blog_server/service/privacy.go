@@ -0,0 +1,36 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"strconv"
+)
+
+// service/privacy.go
+
+// IsFollowing 判断 followerId 是否关注了 userId。
+func IsFollowing(db *gorm.DB, followerId, userId uint) bool {
+	var follower model.User
+	db.Select("id, following").Where("id = ?", followerId).First(&follower)
+	id := strconv.Itoa(int(userId))
+	for _, following := range follower.Following {
+		if following == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CanView 判断访问者能否查看 owner 设置了指定可见范围的内容，viewerId 为 0 表示未登录的访问者。
+// 用户本人总是可以查看自己的内容。
+func CanView(db *gorm.DB, viewerId uint, owner model.User, visibility string) bool {
+	switch {
+	case viewerId != 0 && viewerId == owner.ID:
+		return true
+	case visibility == model.VisibilityFollowers:
+		return viewerId != 0 && IsFollowing(db, viewerId, owner.ID)
+	case visibility == model.VisibilityPrivate:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"blog_server/model"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// service/profile.go
+
+// handlePattern 是个人主页标识的格式：3 到 30 个小写字母、数字、下划线或连字符，以字母或数字开头。
+var handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,29}$`)
+
+// reservedHandles 是不能用作个人主页标识的名称，包括站点的路由和容易被冒用的名称。
+var reservedHandles = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true, "moderator": true, "official": true,
+	"support": true, "help": true, "about": true, "api": true, "www": true, "mail": true,
+	"user": true, "users": true, "me": true, "profile": true, "profiles": true, "settings": true,
+	"login": true, "logout": true, "register": true, "password": true, "oauth": true, "sms": true, "email": true,
+	"article": true, "articles": true, "category": true, "collects": true, "following": true, "followers": true,
+	"likes": true, "notifications": true, "messages": true, "blocks": true, "mutes": true, "reports": true,
+	"events": true, "upload": true, "images": true, "static": true, "null": true, "undefined": true,
+}
+
+// 个人资料相关的错误，错误信息可以直接返回给用户。
+var (
+	ErrHandleFormat   = errors.New("主页标识只能包含 3 到 30 个小写字母、数字、下划线或连字符，并以字母或数字开头")
+	ErrHandleReserved = errors.New("该主页标识为保留名称")
+	ErrHandleTaken    = errors.New("该主页标识已被使用")
+	ErrURLFormat      = errors.New("链接需要以 http:// 或 https:// 开头")
+)
+
+// NormalizeHandle 将个人主页标识转为小写并去掉首尾空白和 @ 前缀。
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// CheckHandle 检查个人主页标识的格式、保留名称以及是否已被其他用户使用。
+func CheckHandle(db *gorm.DB, handle string, userId uint) error {
+	if !handlePattern.MatchString(handle) {
+		return ErrHandleFormat
+	}
+	if reservedHandles[handle] || GetFilter().Check(handle).Action != "" {
+		return ErrHandleReserved
+	}
+	var owner model.User
+	db.Select("id").Where("handle = ?", handle).First(&owner)
+	if owner.ID != 0 && owner.ID != userId {
+		return ErrHandleTaken
+	}
+	return nil
+}
+
+// CheckURL 检查链接是否为 http 或 https 地址，空字符串表示清除链接。
+func CheckURL(link string) error {
+	if link == "" {
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(link) > 255 {
+		return ErrURLFormat
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// service/rank.go
+
+const (
+	rankInterval = 10 * time.Minute // 重新计算排行榜的间隔
+	rankSize     = 100              // 每个排行榜缓存的文章数量
+	rankGravity  = 1.5              // 热度随时间衰减的速度
+
+	// 各项互动在热度中的权重。评论功能尚未实现，暂不计入。
+	viewWeight     = 1.0
+	likeWeight     = 5.0
+	bookmarkWeight = 10.0
+)
+
+// 排行榜的类型
+const (
+	RankHot   = "hot"   // 热门，按随时间衰减的热度排序
+	RankDay   = "day"   // 一天内发布的文章按互动量排序
+	RankWeek  = "week"  // 一周内发布的文章按互动量排序
+	RankMonth = "month" // 一个月内发布的文章按互动量排序
+)
+
+// rankWindows 定义了各类排行榜统计的发布时间范围，0 表示不限制。
+var rankWindows = map[string]time.Duration{
+	RankHot:   0,
+	RankDay:   24 * time.Hour,
+	RankWeek:  7 * 24 * time.Hour,
+	RankMonth: 30 * 24 * time.Hour,
+}
+
+// Ranker 定期计算文章排行榜并缓存在内存中。
+type Ranker struct {
+	db        *gorm.DB
+	mu        sync.RWMutex
+	lists     map[string][]string // 排行榜类型与分类 ID 的组合 -> 按名次排列的文章 ID
+	updatedAt time.Time
+}
+
+// rankArticle 是计算排行榜时需要的文章字段。
+type rankArticle struct {
+	ID         string
+	CategoryId uint
+	LikeCount  int
+	ViewCount  int
+	CreatedAt  time.Time
+}
+
+var ranker *Ranker
+
+// InitRanker 创建排行榜并启动后台定期计算。
+func InitRanker(db *gorm.DB) *Ranker {
+	ranker = &Ranker{db: db, lists: make(map[string][]string)}
+	go func() {
+		for {
+			if err := ranker.Refresh(); err != nil {
+				log.Println("refresh rankings failed:", err)
+			}
+			time.Sleep(rankInterval)
+		}
+	}()
+	return ranker
+}
+
+// GetRanker 返回全局的排行榜。
+func GetRanker() *Ranker {
+	return ranker
+}
+
+// IsRankKind 判断排行榜类型是否合法。
+func IsRankKind(kind string) bool {
+	_, ok := rankWindows[kind]
+	return ok
+}
+
+// Top 返回指定类型排行榜的前 limit 篇文章 ID，categoryId 为 0 时表示全部分类。
+func (r *Ranker) Top(kind string, categoryId uint, limit int) ([]string, time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := r.lists[rankKey(kind, categoryId)]
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, r.updatedAt
+}
+
+// Refresh 重新计算全部排行榜。
+func (r *Ranker) Refresh() error {
+	var articles []rankArticle
+	if err := r.db.Table("articles").Select("id, category_id, like_count, view_count, created_at").
+		Scan(&articles).Error; err != nil {
+		return err
+	}
+	bookmarks, err := BookmarkCounts(r.db)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	lists := make(map[string][]string)
+	for kind, window := range rankWindows {
+		type scored struct {
+			id    string
+			score float64
+		}
+		byCategory := make(map[uint][]scored)
+		for _, a := range articles {
+			if window > 0 && now.Sub(a.CreatedAt) > window {
+				continue
+			}
+			score := viewWeight*float64(a.ViewCount) + likeWeight*float64(a.LikeCount) +
+				bookmarkWeight*float64(bookmarks[a.ID])
+			if kind == RankHot {
+				hours := math.Max(now.Sub(a.CreatedAt).Hours(), 0)
+				score = score / math.Pow(hours+2, rankGravity)
+			}
+			item := scored{a.ID, score}
+			byCategory[0] = append(byCategory[0], item)
+			byCategory[a.CategoryId] = append(byCategory[a.CategoryId], item)
+		}
+		for categoryId, items := range byCategory {
+			sort.SliceStable(items, func(i, j int) bool { return items[i].score > items[j].score })
+			if len(items) > rankSize {
+				items = items[:rankSize]
+			}
+			ids := make([]string, len(items))
+			for i, item := range items {
+				ids[i] = item.id
+			}
+			lists[rankKey(kind, categoryId)] = ids
+		}
+	}
+
+	r.mu.Lock()
+	r.lists = lists
+	r.updatedAt = now
+	r.mu.Unlock()
+	return nil
+}
+
+// BookmarkCounts 统计每篇文章被收藏的次数。
+func BookmarkCounts(db *gorm.DB) (map[string]int, error) {
+	collects, err := LoadCollects(db)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, list := range collects {
+		for _, id := range list {
+			counts[id]++
+		}
+	}
+	return counts, nil
+}
+
+// LoadCollects 读取所有用户的收藏夹，并去除其中的空值和重复值。
+func LoadCollects(db *gorm.DB) ([][]string, error) {
+	var users []model.User
+	if err := db.Select("id, collects").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	collects := make([][]string, 0, len(users))
+	for _, user := range users {
+		seen := make(map[string]bool)
+		var list []string
+		for _, id := range user.Collects {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				list = append(list, id)
+			}
+		}
+		if len(list) > 0 {
+			collects = append(collects, list)
+		}
+	}
+	return collects, nil
+}
+
+// rankKey 返回排行榜在缓存中的键。
+func rankKey(kind string, categoryId uint) string {
+	return kind + "|" + strconv.Itoa(int(categoryId))
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// service/ratelimit.go
+
+// RateStore 是令牌桶的存储后端。
+type RateStore interface {
+	// Take 从 key 对应的令牌桶中取出一个令牌，令牌以每秒 rate 个的速度补充，最多 burst 个。
+	// 令牌不足时返回 false 以及需要等待的时间。
+	Take(key string, rate float64, burst int) (ok bool, retryAfter time.Duration, err error)
+}
+
+var rateStore RateStore = NewMemoryStore()
+
+// InitRateStore 初始化限流和登录失败锁定使用的存储后端，redisAddr 为空时使用内存存储。
+// 多个服务实例需要共享限流状态时，可以使用兼容 Redis 协议的存储。
+func InitRateStore(redisAddr, redisPassword string) RateStore {
+	if redisAddr != "" {
+		store := NewRedisStore(redisAddr, redisPassword)
+		rateStore = store
+		lockoutStore = store
+	}
+	return rateStore
+}
+
+// GetRateStore 返回限流使用的存储后端。
+func GetRateStore() RateStore {
+	return rateStore
+}
+
+// MemoryStore 是基于内存的令牌桶存储，只在单个服务实例内有效。
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time // 返回当前时间，测试时可以替换
+}
+
+// bucket 是一个令牌桶。
+type bucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration // 令牌桶补满所需的时间，超过该时间未使用的令牌桶会被清理
+}
+
+// NewMemoryStore 创建内存令牌桶存储，并定期清理闲置的令牌桶。
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+	go func() {
+		for range time.Tick(time.Minute) {
+			m.cleanup()
+		}
+	}()
+	return m
+}
+
+// Take 实现 RateStore 接口。
+func (m *MemoryStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		m.buckets[key] = b
+	}
+	b.idle = time.Duration(float64(burst) / rate * float64(time.Second))
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+}
+
+// cleanup 清理已经补满的闲置令牌桶。
+func (m *MemoryStore) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// tokenBucketScript 在 Redis 中原子地更新令牌桶，返回是否取得令牌以及需要等待的毫秒数。
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`
+
+// RedisStore 是基于 Redis 协议的令牌桶存储，可以在多个服务实例之间共享限流状态。
+type RedisStore struct {
+	addr     string
+	password string
+	mu       sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+// NewRedisStore 创建 Redis 令牌桶存储，连接会在第一次使用时建立。
+func NewRedisStore(addr, password string) *RedisStore {
+	return &RedisStore{addr: addr, password: password}
+}
+
+// Take 实现 RateStore 接口。
+func (r *RedisStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	reply, err := r.do("EVAL", tokenBucketScript, "1", "ratelimit:"+key,
+		strconv.FormatFloat(rate, 'f', -1, 64), strconv.Itoa(burst), strconv.FormatInt(time.Now().UnixNano()/1e6, 10))
+	if err != nil {
+		return false, 0, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errors.New("unexpected redis reply")
+	}
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
+
+// do 发送一条命令并读取回复，出错时关闭连接，下次使用时重新连接。
+func (r *RedisStore) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := r.command(args...)
+	if err != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return reply, err
+}
+
+// connect 建立连接，设置了密码时进行认证。
+func (r *RedisStore) connect() error {
+	conn, err := net.DialTimeout("tcp", r.addr, 3*time.Second)
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.reader = bufio.NewReader(conn)
+	if r.password != "" {
+		if _, err := r.command("AUTH", r.password); err != nil {
+			conn.Close()
+			r.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// command 按 RESP 协议发送命令并读取回复。
+func (r *RedisStore) command(args ...string) (interface{}, error) {
+	r.conn.SetDeadline(time.Now().Add(3 * time.Second))
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := r.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return r.readReply()
+}
+
+// readReply 读取一条 RESP 回复。
+func (r *RedisStore) readReply() (interface{}, error) {
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("invalid redis reply")
+	}
+	prefix, body := line[0], line[1:len(line)-2]
+	switch prefix {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, errors.New(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = r.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unknown redis reply type %q", prefix)
+}
+
+// TakeToken 使用当前的存储后端取出令牌。存储后端出错时放行请求，避免限流故障导致服务不可用。
+func TakeToken(key string, rate float64, burst int) (bool, time.Duration) {
+	ok, retryAfter, err := rateStore.Take(key, rate, burst)
+	if err != nil {
+		log.Println("rate limit store failed:", err)
+		return true, 0
+	}
+	return ok, retryAfter
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock 是测试使用的时钟，只在调用 Add 时前进。
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemoryStore()
+	m.now = clock.Now
+	take := func(key string) (bool, time.Duration) {
+		ok, wait, err := m.Take(key, 2, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok, wait
+	}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		key     string
+		ok      bool
+		wait    time.Duration
+	}{
+		{"初始令牌 1", 0, "a", true, 0},
+		{"初始令牌 2", 0, "a", true, 0},
+		{"初始令牌 3", 0, "a", true, 0},
+		{"令牌用完", 0, "a", false, 500 * time.Millisecond},
+		{"不同的键互不影响", 0, "b", true, 0},
+		{"补充了半个令牌", 250 * time.Millisecond, "a", false, 250 * time.Millisecond},
+		{"补充了一个令牌", 250 * time.Millisecond, "a", true, 0},
+		{"补充的令牌已用完", 0, "a", false, 500 * time.Millisecond},
+		{"长时间闲置后最多补满 1", 10 * time.Second, "a", true, 0},
+		{"长时间闲置后最多补满 2", 0, "a", true, 0},
+		{"长时间闲置后最多补满 3", 0, "a", true, 0},
+		{"超过容量", 0, "a", false, 500 * time.Millisecond},
+	}
+	for _, step := range steps {
+		clock.Add(step.advance)
+		ok, wait := take(step.key)
+		if ok != step.ok || wait != step.wait {
+			t.Errorf("%s: Take() = %v, %v, want %v, %v", step.name, ok, wait, step.ok, step.wait)
+		}
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemoryStore()
+	m.now = clock.Now
+	m.Take("a", 2, 3)
+	// 补满需要 1.5 秒，之前不会被清理
+	clock.Add(time.Second)
+	m.cleanup()
+	if _, ok := m.buckets["a"]; !ok {
+		t.Fatal("bucket removed before it refilled")
+	}
+	clock.Add(time.Second)
+	m.cleanup()
+	if _, ok := m.buckets["a"]; ok {
+		t.Fatal("idle bucket was not removed")
+	}
+}
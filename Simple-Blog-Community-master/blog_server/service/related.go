@@ -0,0 +1,397 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"html"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// service/related.go
+
+const (
+	relatedInterval  = time.Hour // 重新计算相关文章的间隔
+	relatedFullEvery = 24        // 每隔多少次计算做一次全量计算，其余只计算有变化的文章
+	relatedSize      = 10        // 每篇文章保存的相关文章数量
+	relatedTerms     = 50        // 每篇文章参与相似度计算的关键词数量
+	relatedCollects  = 200       // 单个收藏夹参与共同收藏统计的最大文章数
+	relatedBatch     = 500       // 每条 INSERT 语句写入的推荐结果数量
+
+	// 各项信号在相关度中的权重。文章暂不支持标签，因此不计入标签信号。
+	contentWeight   = 0.6
+	coCollectWeight = 0.3
+	categoryWeight  = 0.1
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// relatedArticle 是计算相关文章时需要的文章字段。
+type relatedArticle struct {
+	ID         string
+	CategoryId uint
+	Title      string
+	Content    string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// relatedRun 记录上一次计算相关文章的时间和当时的文章，用于增量计算。
+var relatedRun struct {
+	last  time.Time
+	known map[string]bool
+	runs  int
+}
+
+// InitRecommender 启动后台定期计算相关文章。
+func InitRecommender(db *gorm.DB) {
+	go func() {
+		for {
+			if err := RefreshRelated(db); err != nil {
+				log.Println("refresh related articles failed:", err)
+			}
+			time.Sleep(relatedInterval)
+		}
+	}()
+}
+
+// RefreshRelated 根据同分类、内容相似度（基于标题和正文的 TF-IDF）以及共同收藏计算相关文章。
+// 通常只重新计算上次计算后新增或修改的文章，以及与它们有共同关键词或共同收藏的文章；
+// 每 relatedFullEvery 次做一次全量计算，使共同收藏和关键词权重的变化也能反映到其他文章上。
+func RefreshRelated(db *gorm.DB) error {
+	start := time.Now()
+	var articles []relatedArticle
+	if err := db.Table("articles").Select("id, category_id, title, content, created_at, updated_at").
+		Order("created_at desc").Scan(&articles).Error; err != nil {
+		return err
+	}
+	collects, err := LoadCollects(db)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(articles))
+	byCategory := make(map[uint][]int)
+	for i, a := range articles {
+		index[a.ID] = i
+		byCategory[a.CategoryId] = append(byCategory[a.CategoryId], i)
+	}
+	full := relatedRun.known == nil || relatedRun.runs%relatedFullEvery == 0
+	var changed []int
+	if !full {
+		kept := 0
+		for i, a := range articles {
+			if !relatedRun.known[a.ID] {
+				changed = append(changed, i)
+				continue
+			}
+			kept++
+			if a.UpdatedAt.After(relatedRun.last) {
+				changed = append(changed, i)
+			}
+		}
+		// 有文章被删除时，推荐了它的文章都需要重新计算，直接做一次全量计算
+		full = kept < len(relatedRun.known)
+	}
+	finish := func() {
+		relatedRun.last = start
+		relatedRun.known = make(map[string]bool, len(articles))
+		for _, a := range articles {
+			relatedRun.known[a.ID] = true
+		}
+		relatedRun.runs++
+	}
+	if !full && len(changed) == 0 {
+		finish()
+		return nil
+	}
+
+	vectors, inverted := contentVectors(articles)
+	co := coCollectSimilarity(collects, index)
+	content := make(map[int]map[int]float64)
+	similar := func(i int) map[int]float64 {
+		if content[i] == nil {
+			content[i] = contentSimilarity(i, vectors, inverted)
+		}
+		return content[i]
+	}
+	// 需要重新计算的文章：全量计算时为所有文章，否则为有变化的文章及其相似文章
+	var targets []int
+	if full {
+		for i := range articles {
+			targets = append(targets, i)
+		}
+	} else {
+		seen := make(map[int]bool)
+		add := func(i int) {
+			if !seen[i] {
+				seen[i] = true
+				targets = append(targets, i)
+			}
+		}
+		for _, i := range changed {
+			add(i)
+			for j := range similar(i) {
+				add(j)
+			}
+			for j := range co[i] {
+				add(j)
+			}
+		}
+	}
+
+	var rows []model.RelatedArticle
+	for _, i := range targets {
+		a := articles[i]
+		candidates, scores := rankRelated(i, articles, similar(i), co[i], byCategory)
+		for pos, j := range candidates {
+			rows = append(rows, model.RelatedArticle{
+				ArticleId: a.ID,
+				RelatedId: articles[j].ID,
+				Score:     scores[j],
+				Position:  pos,
+			})
+		}
+	}
+
+	tx := db.Begin()
+	if full {
+		err = tx.Delete(model.RelatedArticle{}).Error
+	} else {
+		ids := make([]string, len(targets))
+		for k, i := range targets {
+			ids[k] = articles[i].ID
+		}
+		for k := 0; k < len(ids) && err == nil; k += relatedBatch {
+			end := k + relatedBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			err = tx.Where("article_id IN (?)", ids[k:end]).Delete(model.RelatedArticle{}).Error
+		}
+	}
+	if err == nil {
+		err = insertRelated(tx, rows)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	finish()
+	return nil
+}
+
+// rankRelated 计算文章 i 的相关文章，按相关度从高到低返回最多 relatedSize 篇文章的下标以及各自的得分。
+// content 和 co 分别是与其他文章的内容相似度和共同收藏相似度，byCategory 中每个分类的文章按发布时间倒序排列。
+func rankRelated(i int, articles []relatedArticle, content, co map[int]float64, byCategory map[uint][]int) ([]int, map[int]float64) {
+	category := articles[i].CategoryId
+	scores := make(map[int]float64)
+	for j, s := range content {
+		scores[j] += contentWeight * s
+	}
+	for j, s := range co {
+		scores[j] += coCollectWeight * s
+	}
+	for j := range scores {
+		if articles[j].CategoryId == category {
+			scores[j] += categoryWeight
+		}
+	}
+	// 只有同分类信号的文章得分相同，按时间取最新的几篇即可，不需要遍历整个分类
+	added := 0
+	for _, j := range byCategory[category] {
+		if added >= relatedSize {
+			break
+		}
+		if _, ok := scores[j]; !ok && j != i {
+			scores[j] = categoryWeight
+			added++
+		}
+	}
+	candidates := make([]int, 0, len(scores))
+	for j := range scores {
+		candidates = append(candidates, j)
+	}
+	// 得分相同时优先推荐较新的文章
+	sort.Slice(candidates, func(x, y int) bool {
+		if scores[candidates[x]] != scores[candidates[y]] {
+			return scores[candidates[x]] > scores[candidates[y]]
+		}
+		return candidates[x] < candidates[y]
+	})
+	if len(candidates) > relatedSize {
+		candidates = candidates[:relatedSize]
+	}
+	return candidates, scores
+}
+
+// insertRelated 分批写入推荐结果，每批使用一条 INSERT 语句。
+func insertRelated(tx *gorm.DB, rows []model.RelatedArticle) error {
+	for start := 0; start < len(rows); start += relatedBatch {
+		end := start + relatedBatch
+		if end > len(rows) {
+			end = len(rows)
+		}
+		placeholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 4*(end-start))
+		for _, row := range rows[start:end] {
+			placeholders = append(placeholders, "(?, ?, ?, ?)")
+			args = append(args, row.ArticleId, row.RelatedId, row.Score, row.Position)
+		}
+		if err := tx.Exec("INSERT INTO related_articles (article_id, related_id, score, position) VALUES "+
+			strings.Join(placeholders, ", "), args...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// posting 是倒排索引中的一项：包含该关键词的文章及关键词在其中的权重。
+type posting struct {
+	doc    int
+	weight float64
+}
+
+// contentVectors 计算每篇文章归一化的 TF-IDF 向量，只保留权重最高的若干关键词，并建立关键词到文章的倒排索引。
+func contentVectors(articles []relatedArticle) ([]map[string]float64, map[string][]posting) {
+	// 统计词频与文档频率，标题中的词权重加倍
+	docs := make([]map[string]float64, len(articles))
+	df := make(map[string]int)
+	for i, a := range articles {
+		tf := make(map[string]float64)
+		for _, term := range tokenize(a.Title) {
+			tf[term] += 2
+		}
+		for _, term := range tokenize(a.Content) {
+			tf[term]++
+		}
+		for term := range tf {
+			df[term]++
+		}
+		docs[i] = tf
+	}
+
+	inverted := make(map[string][]posting)
+	vectors := make([]map[string]float64, len(articles))
+	n := float64(len(articles))
+	for i, tf := range docs {
+		terms := make([]string, 0, len(tf))
+		for term, count := range tf {
+			tf[term] = count * (math.Log(n/float64(df[term])) + 1)
+			terms = append(terms, term)
+		}
+		sort.Slice(terms, func(x, y int) bool { return tf[terms[x]] > tf[terms[y]] })
+		if len(terms) > relatedTerms {
+			terms = terms[:relatedTerms]
+		}
+		var norm float64
+		for _, term := range terms {
+			norm += tf[term] * tf[term]
+		}
+		norm = math.Sqrt(norm)
+		vector := make(map[string]float64, len(terms))
+		for _, term := range terms {
+			vector[term] = tf[term] / norm
+			inverted[term] = append(inverted[term], posting{i, vector[term]})
+		}
+		vectors[i] = vector
+	}
+	return vectors, inverted
+}
+
+// contentSimilarity 通过倒排索引计算文章 i 与其他文章的余弦相似度，只比较有共同关键词的文章。
+func contentSimilarity(i int, vectors []map[string]float64, inverted map[string][]posting) map[int]float64 {
+	sims := make(map[int]float64)
+	for term, weight := range vectors[i] {
+		for _, p := range inverted[term] {
+			if p.doc != i {
+				sims[p.doc] += weight * p.weight
+			}
+		}
+	}
+	return sims
+}
+
+// coCollectSimilarity 根据共同收藏次数计算文章之间的相似度，使用收藏次数做余弦归一化。
+func coCollectSimilarity(collects [][]string, index map[string]int) []map[int]float64 {
+	counts := make(map[int]float64)
+	pairs := make(map[int]map[int]float64)
+	for _, list := range collects {
+		var docs []int
+		for _, id := range list {
+			if i, ok := index[id]; ok {
+				docs = append(docs, i)
+			}
+		}
+		if len(docs) > relatedCollects {
+			docs = docs[:relatedCollects]
+		}
+		for _, i := range docs {
+			counts[i]++
+			for _, j := range docs {
+				if i == j {
+					continue
+				}
+				if pairs[i] == nil {
+					pairs[i] = make(map[int]float64)
+				}
+				pairs[i][j]++
+			}
+		}
+	}
+	sims := make([]map[int]float64, len(index))
+	for i, row := range pairs {
+		sims[i] = make(map[int]float64, len(row))
+		for j, co := range row {
+			sims[i][j] = co / math.Sqrt(counts[i]*counts[j])
+		}
+	}
+	return sims
+}
+
+// tokenize 将文本切分为关键词：去除 HTML 标签后，英文和数字按单词切分，中文按相邻两个字切分。
+func tokenize(text string) []string {
+	text = strings.ToLower(html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " ")))
+	var terms []string
+	var word []rune
+	var han []rune
+	flush := func() {
+		if len(word) >= 2 {
+			terms = append(terms, string(word))
+		}
+		word = word[:0]
+		if len(han) == 1 {
+			terms = append(terms, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
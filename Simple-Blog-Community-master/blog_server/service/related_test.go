@@ -0,0 +1,64 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankRelated(t *testing.T) {
+	// 0~14 属于分类 1，按发布时间倒序排列；15 单独属于分类 2
+	articles := make([]relatedArticle, 16)
+	byCategory := make(map[uint][]int)
+	for i := range articles {
+		articles[i].CategoryId = 1
+		if i == 15 {
+			articles[i].CategoryId = 2
+		}
+		byCategory[articles[i].CategoryId] = append(byCategory[articles[i].CategoryId], i)
+	}
+	tests := []struct {
+		name    string
+		i       int
+		content map[int]float64
+		co      map[int]float64
+		want    []int
+		scored  int // 参与排序的候选文章数量，同分类只补充 relatedSize 篇
+	}{
+		{"只有同分类信号时取最新的文章", 0, nil, nil, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 10},
+		{"跳过文章自身", 5, nil, nil, []int{0, 1, 2, 3, 4, 6, 7, 8, 9, 10}, 10},
+		{"内容和共同收藏优先于同分类", 0, map[int]float64{7: 0.5, 15: 0.9}, map[int]float64{3: 0.4},
+			[]int{15, 7, 3, 1, 2, 4, 5, 6, 8, 9}, 13},
+		{"分类内没有其他文章", 15, nil, nil, []int{}, 0},
+		{"其他分类的文章只按内容计分", 15, map[int]float64{2: 0.1}, nil, []int{2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, scores := rankRelated(tt.i, articles, tt.content, tt.co, byCategory)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidates = %v, want %v", got, tt.want)
+			}
+			if len(scores) != tt.scored {
+				t.Errorf("scored %d candidates, want %d", len(scores), tt.scored)
+			}
+		})
+	}
+}
+
+func TestContentSimilarity(t *testing.T) {
+	articles := []relatedArticle{
+		{Title: "golang concurrency", Content: "goroutine channel select"},
+		{Title: "golang channels", Content: "channel buffered goroutine"},
+		{Title: "baking bread", Content: "flour yeast oven"},
+	}
+	vectors, inverted := contentVectors(articles)
+	sims := contentSimilarity(0, vectors, inverted)
+	if sims[1] <= 0 {
+		t.Errorf("similarity with shared terms = %v, want > 0", sims[1])
+	}
+	if _, ok := sims[2]; ok {
+		t.Errorf("article without shared terms should not be compared")
+	}
+	if _, ok := sims[0]; ok {
+		t.Errorf("article should not be compared with itself")
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"blog_server/common"
+	"blog_server/model"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+// service/session.go
+
+const (
+	lastSeenInterval     = time.Minute        // 更新会话最近使用时间的最小间隔，避免每个请求都写数据库
+	sessionRetention     = 7 * 24 * time.Hour // 注销或过期的会话保留的时间，便于排查异常登录
+	sessionCleanInterval = time.Hour          // 清理会话的间隔
+)
+
+// StartSession 为用户创建会话并发放对应的 token，记录登录的设备、IP 和 User-Agent。
+func StartSession(db *gorm.DB, c *gin.Context, user model.User) (string, error) {
+	now := time.Now()
+	userAgent := truncate(c.Request.UserAgent(), 255)
+	session := model.Session{
+		ID:         uuid.NewV4().String(),
+		UserId:     user.ID,
+		Device:     DeviceName(userAgent),
+		IP:         c.ClientIP(),
+		UserAgent:  userAgent,
+		LastSeenAt: model.Time(now),
+		ExpiresAt:  model.Time(now.Add(common.TokenTTL)),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return common.ReleaseToken(user, session.ID, time.Time(session.ExpiresAt))
+}
+
+// TouchSession 更新会话的最近使用时间，距离上次更新不足 lastSeenInterval 时跳过。
+func TouchSession(db *gorm.DB, session model.Session) {
+	if time.Since(time.Time(session.LastSeenAt)) < lastSeenInterval {
+		return
+	}
+	db.Model(&session).UpdateColumn("last_seen_at", time.Now())
+}
+
+// RevokeSession 注销用户的指定会话并断开使用该会话建立的推送连接，会话不存在或已注销时返回 false。
+func RevokeSession(db *gorm.DB, userId uint, id string) (bool, error) {
+	result := db.Model(&model.Session{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	hub.CloseSession(id)
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeSessions 注销用户的会话并断开对应的推送连接，except 不为空时保留该会话。
+func RevokeSessions(db *gorm.DB, userId uint, except string) error {
+	err := db.Model(&model.Session{}).Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, except).
+		UpdateColumn("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+	hub.CloseUser(userId, except)
+	return nil
+}
+
+// InitSessionCleaner 启动后台任务，定期删除注销或过期超过 sessionRetention 的会话。
+func InitSessionCleaner(db *gorm.DB) {
+	go func() {
+		for {
+			before := time.Now().Add(-sessionRetention)
+			if err := db.Where("revoked_at < ? OR expires_at < ?", before, before).Delete(&model.Session{}).Error; err != nil {
+				log.Println("purge sessions failed:", err)
+			}
+			time.Sleep(sessionCleanInterval)
+		}
+	}()
+}
+
+// DeviceName 根据 User-Agent 识别浏览器和操作系统，例如 Chrome on Windows。
+func DeviceName(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"MicroMessenger", "WeChat"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	})
+	system := firstMatch(userAgent, [][2]string{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	})
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "未知设备"
+}
+
+// firstMatch 返回第一个在 s 中出现的关键字对应的名称。
+func firstMatch(s string, rules [][2]string) string {
+	for _, rule := range rules {
+		if strings.Contains(s, rule[0]) {
+			return rule[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// service/sms.go
+
+// SMSSender 是短信的发送渠道，接入短信服务商时实现该接口即可。
+type SMSSender interface {
+	Send(phoneNumber, content string) error
+}
+
+var smsSender SMSSender = ConsoleSender{}
+
+// InitSMSSender 根据配置选择短信发送渠道：为空或 "console" 时输出到日志，
+// "file:<路径>" 时追加写入文件，便于开发和测试时读取验证码。
+func InitSMSSender(config string) SMSSender {
+	switch {
+	case config == "" || config == "console":
+		smsSender = ConsoleSender{}
+	case strings.HasPrefix(config, "file:"):
+		smsSender = &FileSender{Path: strings.TrimPrefix(config, "file:")}
+	default:
+		log.Printf("unknown sms sender %q, using console", config)
+		smsSender = ConsoleSender{}
+	}
+	return smsSender
+}
+
+// SetSMSSender 替换短信发送渠道，用于接入短信服务商。
+func SetSMSSender(sender SMSSender) {
+	smsSender = sender
+}
+
+// GetSMSSender 返回当前的短信发送渠道。
+func GetSMSSender() SMSSender {
+	return smsSender
+}
+
+// ConsoleSender 将短信内容输出到日志，仅用于开发环境。
+type ConsoleSender struct{}
+
+// Send 实现 SMSSender 接口。
+func (ConsoleSender) Send(phoneNumber, content string) error {
+	log.Printf("[SMS] to %s: %s", phoneNumber, content)
+	return nil
+}
+
+// FileSender 将短信内容逐行追加到文件中，用于开发和测试。
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send 实现 SMSSender 接口。
+func (f *FileSender) Send(phoneNumber, content string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phoneNumber, content)
+	return err
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"log"
+	"time"
+)
+
+// service/suspension.go
+
+// reinstateInterval 是检查封禁是否到期的间隔。
+const reinstateInterval = time.Minute
+
+// SuspendUser 封禁用户至 until，并断开用户的推送连接，hideContent 为 true 时封禁期间隐藏该用户的文章。
+func SuspendUser(db *gorm.DB, user model.User, until time.Time, reason string, hideContent bool) error {
+	err := db.Model(&user).Updates(map[string]interface{}{
+		"suspended_until": until,
+		"suspend_reason":  reason,
+		"content_hidden":  hideContent,
+	}).Error
+	if err != nil {
+		return err
+	}
+	hub.CloseUser(user.ID, "")
+	return nil
+}
+
+// ReinstateUser 解除用户的封禁，并恢复其文章的显示。
+func ReinstateUser(db *gorm.DB, user model.User) error {
+	return db.Model(&user).Updates(map[string]interface{}{
+		"suspended_until": nil,
+		"suspend_reason":  "",
+		"content_hidden":  false,
+	}).Error
+}
+
+// InitReinstater 启动后台定期检查，自动解除已到期的封禁。
+func InitReinstater(db *gorm.DB) {
+	go func() {
+		for {
+			if err := reinstateExpired(db); err != nil {
+				log.Println("reinstate users failed:", err)
+			}
+			time.Sleep(reinstateInterval)
+		}
+	}()
+}
+
+// reinstateExpired 解除所有已到期的封禁，并通知被解封的用户。
+func reinstateExpired(db *gorm.DB) error {
+	var users []model.User
+	if err := db.Where("suspended_until IS NOT NULL AND suspended_until <= ?", time.Now()).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := ReinstateUser(db, user); err != nil {
+			return err
+		}
+		Notify(db, user.ID, 0, model.NotifySystem, "", "你的账号封禁已到期，现已恢复正常使用")
+	}
+	return nil
+}
+
+// SuspensionMessage 返回提示用户账号被封禁的信息。
+func SuspensionMessage(user model.User) string {
+	msg := "账号已被永久封禁"
+	if !user.IsPermanentlySuspended() {
+		msg = "账号已被封禁至 " + user.SuspendedUntil.String()
+	}
+	if user.SuspendReason != "" {
+		msg += "，原因：" + user.SuspendReason
+	}
+	return msg
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// service/ticket.go
+
+// StreamTicketTTL 是实时推送票据的有效期，客户端获取票据后应立即建立连接。
+const StreamTicketTTL = 30 * time.Second
+
+// streamTicket 是一张实时推送票据，保存签发时请求携带的 Authorization 字段，使用时按原凭证重新校验。
+type streamTicket struct {
+	authorization string
+	expiresAt     time.Time
+}
+
+var (
+	ticketMu sync.Mutex
+	tickets  = make(map[string]streamTicket)
+)
+
+// IssueStreamTicket 为 EventSource 等无法设置请求头的客户端签发一次性票据。
+// 票据代替 token 出现在连接地址中，即使被记录到访问日志，也已使用或很快过期。
+func IssueStreamTicket(authorization string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
+	now := time.Now()
+	ticketMu.Lock()
+	defer ticketMu.Unlock()
+	for key, t := range tickets {
+		if now.After(t.expiresAt) {
+			delete(tickets, key)
+		}
+	}
+	tickets[ticket] = streamTicket{authorization: authorization, expiresAt: now.Add(StreamTicketTTL)}
+	return ticket, nil
+}
+
+// RedeemStreamTicket 使用票据并返回签发时的 Authorization 字段，票据不存在或已过期时返回 false。
+func RedeemStreamTicket(ticket string) (string, bool) {
+	ticketMu.Lock()
+	defer ticketMu.Unlock()
+	t, ok := tickets[ticket]
+	if !ok {
+		return "", false
+	}
+	delete(tickets, ticket)
+	if time.Now().After(t.expiresAt) {
+		return "", false
+	}
+	return t.authorization, true
+}
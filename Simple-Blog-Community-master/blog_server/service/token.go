@@ -0,0 +1,56 @@
+package service
+
+import (
+	"blog_server/model"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+// service/token.go
+
+// CreateAccessToken 为用户创建个人访问令牌，返回令牌明文，明文只展示给用户一次。
+func CreateAccessToken(db *gorm.DB, userId uint, name string, scopes []string, expiresAt *model.Time) (string, model.AccessToken, error) {
+	b := make([]byte, 30)
+	if _, err := rand.Read(b); err != nil {
+		return "", model.AccessToken{}, err
+	}
+	plain := model.AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	token := model.AccessToken{
+		UserId:    userId,
+		Name:      name,
+		Hint:      plain[:len(model.AccessTokenPrefix)+6],
+		TokenHash: hashAccessToken(plain),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(&token).Error; err != nil {
+		return "", token, err
+	}
+	return plain, token, nil
+}
+
+// FindAccessToken 根据令牌明文查询有效的个人访问令牌。
+func FindAccessToken(db *gorm.DB, plain string) (model.AccessToken, bool) {
+	var token model.AccessToken
+	db.Where("token_hash = ?", hashAccessToken(plain)).First(&token)
+	return token, token.ID != 0 && token.Active()
+}
+
+// TouchAccessToken 记录令牌的最近使用时间和 IP，距离上次记录不足 lastSeenInterval 时跳过。
+func TouchAccessToken(db *gorm.DB, token model.AccessToken, ip string) {
+	if token.LastUsedAt != nil && time.Since(time.Time(*token.LastUsedAt)) < lastSeenInterval && token.LastUsedIP == ip {
+		return
+	}
+	db.Model(&token).UpdateColumns(map[string]interface{}{"last_used_at": time.Now(), "last_used_ip": ip})
+}
+
+// hashAccessToken 计算令牌的哈希值。令牌本身有足够的随机性，不需要加盐。
+func hashAccessToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
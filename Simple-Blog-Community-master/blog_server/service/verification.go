@@ -0,0 +1,141 @@
+package service
+
+import (
+	"blog_server/model"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"math/big"
+	"time"
+)
+
+// service/verification.go
+
+const (
+	codeLength      = 6                // 验证码位数
+	codeTTL         = 10 * time.Minute // 验证码有效期
+	codeMaxAttempts = 5                // 每个验证码最多尝试验证的次数
+	resendInterval  = time.Minute      // 同一手机号同一用途两次发送的最小间隔
+	dailyCodeLimit  = 10               // 同一手机号每天最多发送的验证码数量
+	verifiedTTL     = 30 * time.Minute // 验证通过后需要在该时间内完成后续操作
+)
+
+// 验证码相关的错误，错误信息可以直接返回给用户。
+var (
+	ErrCodeDailyLimit = errors.New("今日验证码发送次数已达上限")
+	ErrCodeInvalid    = errors.New("验证码错误")
+	ErrCodeExpired    = errors.New("验证码已过期，请重新获取")
+	ErrCodeAttempts   = errors.New("验证码错误次数过多，请重新获取")
+	ErrNotVerified    = errors.New("手机号未验证")
+)
+
+// ResendError 表示距离上次发送验证码的时间过短。
+type ResendError struct {
+	RetryAfter time.Duration
+}
+
+func (e ResendError) Error() string {
+	return fmt.Sprintf("验证码发送过于频繁，请%d秒后再试", int(e.RetryAfter.Seconds())+1)
+}
+
+// SendCode 生成验证码并通过短信发送，数据库中只保存验证码的哈希值。
+func SendCode(db *gorm.DB, phoneNumber, purpose string) error {
+	var last model.VerificationCode
+	db.Where("phone_number = ? AND purpose = ?", phoneNumber, purpose).Order("id DESC").First(&last)
+	if last.ID != 0 {
+		if wait := resendInterval - time.Since(time.Time(last.CreatedAt)); wait > 0 {
+			return ResendError{RetryAfter: wait}
+		}
+	}
+	var count int
+	db.Model(&model.VerificationCode{}).Where("phone_number = ? AND created_at > ?", phoneNumber, time.Now().Add(-24*time.Hour)).Count(&count)
+	if count >= dailyCodeLimit {
+		return ErrCodeDailyLimit
+	}
+
+	code, err := randomCode()
+	if err != nil {
+		return err
+	}
+	record := model.VerificationCode{
+		PhoneNumber: phoneNumber,
+		Purpose:     purpose,
+		CodeHash:    hashCode(phoneNumber, purpose, code),
+		ExpiresAt:   model.Time(time.Now().Add(codeTTL)),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return err
+	}
+	content := fmt.Sprintf("您的验证码是 %s，%d 分钟内有效，请勿泄露给他人。", code, int(codeTTL.Minutes()))
+	if err := smsSender.Send(phoneNumber, content); err != nil {
+		// 发送失败的验证码不计入发送间隔和次数
+		db.Delete(&record)
+		return err
+	}
+	return nil
+}
+
+// VerifyCode 校验手机号最近一次收到的验证码，通过后记录验证时间。
+func VerifyCode(db *gorm.DB, phoneNumber, purpose, code string) error {
+	var record model.VerificationCode
+	db.Where("phone_number = ? AND purpose = ? AND used_at IS NULL", phoneNumber, purpose).Order("id DESC").First(&record)
+	if record.ID == 0 {
+		return ErrCodeInvalid
+	}
+	if record.VerifiedAt == nil && time.Now().After(time.Time(record.ExpiresAt)) {
+		return ErrCodeExpired
+	}
+	// 先原子地占用一次尝试次数再比较验证码，避免并发请求同时通过次数检查
+	result := db.Model(&model.VerificationCode{}).Where("id = ? AND attempts < ?", record.ID, codeMaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return ErrCodeAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCode(phoneNumber, purpose, code)), []byte(record.CodeHash)) != 1 {
+		return ErrCodeInvalid
+	}
+	// 已经验证过的验证码可以再次校验，例如先校验验证码再提交重置密码
+	if record.VerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	return db.Model(&record).UpdateColumn("verified_at", &now).Error
+}
+
+// ConsumeVerification 使用一次验证结果，例如注册时确认手机号已经通过验证。
+// 验证结果只能使用一次，并且需要在验证通过后的一段时间内使用。
+func ConsumeVerification(db *gorm.DB, phoneNumber, purpose string) error {
+	now := time.Now()
+	result := db.Model(&model.VerificationCode{}).
+		Where("phone_number = ? AND purpose = ? AND used_at IS NULL AND verified_at > ?", phoneNumber, purpose, now.Add(-verifiedTTL)).
+		UpdateColumn("used_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotVerified
+	}
+	return nil
+}
+
+// randomCode 生成指定位数的随机数字验证码。
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Exp(big.NewInt(10), big.NewInt(codeLength), nil))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeLength, n.Int64()), nil
+}
+
+// hashCode 计算验证码的哈希值，加入手机号和用途，使相同的验证码在不同记录中的哈希值不同。
+func hashCode(phoneNumber, purpose, code string) string {
+	sum := sha256.Sum256([]byte(purpose + ":" + phoneNumber + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"blog_server/model"
+	"github.com/jinzhu/gorm"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// service/view.go
+
+const (
+	viewWindow        = 30 * time.Minute // 同一访客在该时间窗口内重复浏览同一篇文章只计一次
+	viewFlushInterval = 10 * time.Second // 定期将内存中的浏览量写入数据库的间隔
+	viewFlushSize     = 500              // 内存中累计的浏览量达到该值时立即写入数据库
+)
+
+// botKeywords 是判定爬虫的 User-Agent 关键字（小写）。
+var botKeywords = []string{
+	"bot", "spider", "crawl", "slurp", "curl", "wget", "python", "java/", "go-http-client",
+	"okhttp", "httpclient", "headless", "phantomjs", "scrapy", "facebookexternalhit", "preview",
+}
+
+// ViewCounter 在内存中聚合文章浏览量，并按批次写入数据库。
+type ViewCounter struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	seen    map[string]time.Time // 访客与文章的组合 -> 最近一次计数的时间
+	pending map[string]int       // 文章 ID -> 尚未写入数据库的浏览量
+	writing map[string]int       // 文章 ID -> 正在写入数据库、尚未提交的浏览量
+	total   int                  // 尚未写入数据库的浏览量总数
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+var views *ViewCounter
+
+// InitViewCounter 创建浏览量计数器并启动后台写入协程。
+func InitViewCounter(db *gorm.DB) *ViewCounter {
+	views = &ViewCounter{
+		db:      db,
+		seen:    make(map[string]time.Time),
+		pending: make(map[string]int),
+		flushCh: make(chan struct{}, 1),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	go views.run()
+	return views
+}
+
+// GetViewCounter 返回全局的浏览量计数器。
+func GetViewCounter() *ViewCounter {
+	return views
+}
+
+// IsBot 根据 User-Agent 判断请求是否来自爬虫或脚本，空 User-Agent 同样视为爬虫。
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, keyword := range botKeywords {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record 记录一次浏览，返回本次浏览是否被计数。
+// 爬虫请求以及同一访客在时间窗口内的重复浏览不会被计数。
+func (v *ViewCounter) Record(articleId, visitor, userAgent string) bool {
+	if IsBot(userAgent) {
+		return false
+	}
+	key := visitor + "|" + articleId
+	now := time.Now()
+	v.mu.Lock()
+	if last, ok := v.seen[key]; ok && now.Sub(last) < viewWindow {
+		v.mu.Unlock()
+		return false
+	}
+	v.seen[key] = now
+	v.pending[articleId]++
+	v.total++
+	full := v.total >= viewFlushSize
+	v.mu.Unlock()
+	// 累计量过大时通知后台协程立即写入
+	if full {
+		select {
+		case v.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Pending 返回文章尚未写入数据库的浏览量，用于展示实时的浏览数。
+func (v *ViewCounter) Pending(articleId string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pending[articleId] + v.writing[articleId]
+}
+
+// Flush 将内存中累计的浏览量在一个事务中批量写入数据库，失败时放回内存待下次写入。
+// 写入期间的新浏览计入新的 map，已取出的浏览量在提交前仍计入 Pending，避免展示的浏览数回落。
+func (v *ViewCounter) Flush() error {
+	v.mu.Lock()
+	batch := v.pending
+	v.pending = make(map[string]int)
+	v.writing = batch
+	v.total = 0
+	// 顺带清理已经超出时间窗口的去重记录
+	now := time.Now()
+	for key, last := range v.seen {
+		if now.Sub(last) >= viewWindow {
+			delete(v.seen, key)
+		}
+	}
+	v.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx := v.db.Begin()
+	if err := tx.Error; err != nil {
+		v.restore(batch)
+		return err
+	}
+	for id, n := range batch {
+		if err := tx.Model(model.Article{}).Where("id = ?", id).
+			UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+			tx.Rollback()
+			v.restore(batch)
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		v.restore(batch)
+		return err
+	}
+	v.mu.Lock()
+	v.writing = nil
+	v.mu.Unlock()
+	return nil
+}
+
+// Stop 停止后台协程，并写入剩余的浏览量。
+func (v *ViewCounter) Stop() {
+	close(v.stopCh)
+	<-v.doneCh
+}
+
+// restore 将写入失败的浏览量合并回内存中，与写入期间新增的浏览量累加。
+func (v *ViewCounter) restore(batch map[string]int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.writing = nil
+	for id, n := range batch {
+		v.pending[id] += n
+		v.total += n
+	}
+}
+
+// run 是后台写入协程，定期或在累计量过大时写入数据库。
+func (v *ViewCounter) run() {
+	defer close(v.doneCh)
+	ticker := time.NewTicker(viewFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-v.flushCh:
+		case <-v.stopCh:
+			if err := v.Flush(); err != nil {
+				log.Println("flush views failed:", err)
+			}
+			return
+		}
+		if err := v.Flush(); err != nil {
+			log.Println("flush views failed:", err)
+		}
+	}
+}
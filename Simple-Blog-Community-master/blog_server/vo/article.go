@@ -7,3 +7,16 @@ type CreateArticleRequest struct {
 	Content    string `json:"content" binging:"required"`
 	HeadImage  string `json:"head_image"`
 }
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type" binding:"required"`
+	TargetId   string `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	Detail     string `json:"detail"`
+}
+
+type HandleReportRequest struct {
+	Action string `json:"action" binding:"required"`
+	Note   string `json:"note"`
+	Days   int    `json:"days"` // 封禁天数，仅在封禁作者时使用
+}
@@ -0,0 +1,5 @@
+package vo
+
+type SendMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
@@ -0,0 +1,93 @@
+package vo
+
+type SuspendUserRequest struct {
+	Days        int    `json:"days"`      // 封禁天数，永久封禁时忽略
+	Permanent   bool   `json:"permanent"` // 是否永久封禁
+	Reason      string `json:"reason" binding:"required"`
+	HideContent bool   `json:"hide_content"` // 封禁期间是否隐藏该用户的文章
+}
+
+type SendCodeRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Purpose     string `json:"purpose" binding:"required"` // 验证码用途，见 model.VerificationPurposes
+}
+
+type VerifyCodeRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Purpose     string `json:"purpose" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Code        string `json:"code" binding:"required"` // 通过 /sms/code 获取的重置密码验证码
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type UpdateEmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordByEmailRequest struct {
+	Token       string `json:"token" binding:"required"` // 重置密码邮件中的令牌
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // 验证器应用中的动态验证码
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // 动态验证码或恢复码
+}
+
+type ConfirmOAuthLinkRequest struct {
+	Token string `json:"token" binding:"required"` // 绑定外部账号后回调地址中的 confirm 参数
+}
+
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge" binding:"required"` // 登录时返回的挑战令牌
+	Code      string `json:"code" binding:"required"`      // 动态验证码或恢复码
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"` // 权限范围，见 model.AccessTokenScopes
+	ExpiresInDays int      `json:"expires_in_days"`           // 有效天数，为 0 时永不过期
+}
+
+// UpdateProfileRequest 中为空的字段表示不修改，空字符串表示清除。
+type UpdateProfileRequest struct {
+	UserName    *string            `json:"user_name"`
+	Avatar      *string            `json:"avatar"`
+	Handle      *string            `json:"handle"`
+	Bio         *string            `json:"bio"`
+	Location    *string            `json:"location"`
+	Website     *string            `json:"website"`
+	CoverImage  *string            `json:"cover_image"`
+	SocialLinks *map[string]string `json:"social_links"` // 键见 model.SocialPlatforms，整体替换
+}
+
+// UpdatePrivacyRequest 中为空的字段表示不修改，取值见 model.Visibilities。
+type UpdatePrivacyRequest struct {
+	Profile   *string `json:"profile"`
+	Bookmarks *string `json:"bookmarks"`
+	Following *string `json:"following"`
+	Followers *string `json:"followers"`
+}
+
+type RequestDeletionRequest struct {
+	Password string `json:"password"` // 当前密码，没有密码的外部登录账号可以提供两步验证码，或在最近 10 分钟内重新登录后申请
+	Mode     string `json:"mode"`     // 注销时对文章的处理方式，见 model.DeletionModes，默认保留文章并匿名
+	Code     string `json:"code"`     // 开启两步验证时需要提供动态验证码或恢复码
+}